@@ -11,29 +11,14 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/dusted-go/logging/v2/levels"
 )
 
 const (
 	timeFormat = "[15:04:05.000]"
 
 	reset = "\033[0m"
-
-	black        = 30
-	red          = 31
-	green        = 32
-	yellow       = 33
-	blue         = 34
-	magenta      = 35
-	cyan         = 36
-	lightGray    = 37
-	darkGray     = 90
-	lightRed     = 91
-	lightGreen   = 92
-	lightYellow  = 93
-	lightBlue    = 94
-	lightMagenta = 95
-	lightCyan    = 96
-	white        = 97
 )
 
 func colorizer(colorCode int, v string) string {
@@ -101,21 +86,8 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	}
 
 	if !levelAttr.Equal(slog.Attr{}) {
-		level = levelAttr.Value.String() + ":"
-
-		if r.Level <= slog.LevelDebug {
-			level = colorize(lightGray, level)
-		} else if r.Level <= slog.LevelInfo {
-			level = colorize(cyan, level)
-		} else if r.Level < slog.LevelWarn {
-			level = colorize(lightBlue, level)
-		} else if r.Level < slog.LevelError {
-			level = colorize(lightYellow, level)
-		} else if r.Level <= slog.LevelError+1 {
-			level = colorize(lightRed, level)
-		} else if r.Level > slog.LevelError+1 {
-			level = colorize(lightMagenta, level)
-		}
+		levelAttr = levels.ReplaceAttr(nil, levelAttr)
+		level = colorize(levels.Color(r.Level), levelAttr.Value.String()+":")
 	}
 
 	var timestamp string
@@ -127,7 +99,7 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 		timeAttr = h.r([]string{}, timeAttr)
 	}
 	if !timeAttr.Equal(slog.Attr{}) {
-		timestamp = colorize(lightGray, timeAttr.Value.String())
+		timestamp = colorize(levels.LightGray, timeAttr.Value.String())
 	}
 
 	var msg string
@@ -139,7 +111,7 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 		msgAttr = h.r([]string{}, msgAttr)
 	}
 	if !msgAttr.Equal(slog.Attr{}) {
-		msg = colorize(white, msgAttr.Value.String())
+		msg = colorize(levels.White, msgAttr.Value.String())
 	}
 
 	attrs, err := h.computeAttrs(ctx, r)
@@ -173,7 +145,7 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 		out.WriteString(" ")
 	}
 	if len(attrsAsBytes) > 0 {
-		out.WriteString(colorize(darkGray, string(attrsAsBytes)))
+		out.WriteString(colorize(levels.DarkGray, string(attrsAsBytes)))
 	}
 
 	_, err = io.WriteString(h.writer, out.String()+"\n")
@@ -1,10 +1,13 @@
 package prettylog
 
 import (
+	"context"
 	"log/slog"
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/dusted-go/logging/v2/levels"
 )
 
 type captureStream struct {
@@ -55,3 +58,21 @@ func Test_SkipEmptyAttributes(t *testing.T) {
 		t.Errorf("exected line to be terminated with `\\n` but found `%s`", line[len(line)-1:])
 	}
 }
+
+func Test_UsesRegisteredLevelNames(t *testing.T) {
+	cs := &captureStream{}
+	handler := New(&slog.HandlerOptions{Level: slog.LevelDebug}, WithDestinationWriter(cs))
+	logger := slog.New(handler)
+
+	logger.Log(context.Background(), levels.NOTICE, "testing logger")
+	logger.Log(context.Background(), levels.CRITICAL+1, "testing logger")
+	if len(cs.lines) != 2 {
+		t.Fatalf("expected 2 lines logged, got: %d", len(cs.lines))
+	}
+
+	for i, want := range []string{" NOTICE: ", " CRITICAL+1: "} {
+		if line := string(cs.lines[i]); !strings.Contains(line, want) {
+			t.Errorf("expected `%s` but found `%s`", want, line)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package stackdriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dusted-go/logging/v2/levels"
+)
+
+// DefaultAPIEndpoint is the Cloud Logging entries:write REST endpoint:
+// - https://cloud.google.com/logging/docs/reference/v2/rest/v2/entries/write
+const DefaultAPIEndpoint = "https://logging.googleapis.com/v2/entries:write"
+
+// ErrWriterClosed is returned when writing to a closed APIWriter.
+var ErrWriterClosed = errors.New("stackdriver: write to closed APIWriter")
+
+// ErrQueueFull is returned when writing to an APIWriter whose queue holds
+// MaxQueueEntries entries. The entry is dropped.
+var ErrQueueFull = errors.New("stackdriver: APIWriter queue is full")
+
+// MonitoredResource identifies the resource which produced the log entries.
+type MonitoredResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// APIWriterOptions holds configuration for an APIWriter.
+type APIWriterOptions struct {
+	// ProjectID is the Google Cloud project which receives the log entries.
+	ProjectID string
+	// LogID is the name of the log. If empty, "app" is used.
+	LogID string
+	// Resource is the monitored resource of the log entries.
+	// If nil, the "global" resource is used.
+	Resource *MonitoredResource
+	// Endpoint is the URL of the entries:write method.
+	// If empty, DefaultAPIEndpoint is used.
+	Endpoint string
+	// HTTPClient sends the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// TokenSource returns the OAuth2 access token for each request.
+	// If nil, requests are sent without an Authorization header.
+	TokenSource func(ctx context.Context) (string, error)
+	// MaxBatchEntries is the largest number of entries sent in one request.
+	// If zero, 1000 is used.
+	MaxBatchEntries int
+	// MaxBatchBytes is the largest approximate size of one request.
+	// If zero, 5 MiB is used.
+	MaxBatchBytes int
+	// MaxQueueEntries is the largest number of entries which wait to be
+	// sent. Entries written to a full queue are dropped. If zero, 10000 is
+	// used.
+	MaxQueueEntries int
+	// FlushInterval is the longest time an entry waits before it is sent.
+	// If zero, 5 seconds is used.
+	FlushInterval time.Duration
+	// MaxRetries is the number of times a failed request is retried.
+	// If zero, 5 is used. A negative value disables retries.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled on each
+	// subsequent retry. If zero, 100 milliseconds is used.
+	MinBackoff time.Duration
+	// MaxBackoff is the longest delay between retries.
+	// If zero, 10 seconds is used.
+	MaxBackoff time.Duration
+	// OnError is called with errors which occur when sending entries in the
+	// background, including the number of entries which were dropped because
+	// the queue was full or a request failed after all retries. If nil,
+	// errors are written to os.Stderr.
+	OnError func(error)
+}
+
+// APIWriter is an io.Writer which sends the JSON entries written by a
+// Handler to the Cloud Logging API. Entries are batched and sent in the
+// background. Close must be called to send the remaining entries on shutdown.
+type APIWriter struct {
+	opts    APIWriterOptions
+	logName string
+	dropped atomic.Int64
+
+	mu        sync.Mutex
+	pending   []map[string]any
+	size      int
+	queueFull int
+	closed    bool
+
+	sendMu  sync.Mutex
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewAPIWriter creates an APIWriter and starts its background flushing.
+func NewAPIWriter(opts APIWriterOptions) *APIWriter {
+	if opts.LogID == "" {
+		opts.LogID = "app"
+	}
+	if opts.Resource == nil {
+		opts.Resource = &MonitoredResource{Type: "global"}
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = DefaultAPIEndpoint
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.MaxBatchEntries <= 0 {
+		opts.MaxBatchEntries = 1000
+	}
+	if opts.MaxBatchBytes <= 0 {
+		opts.MaxBatchBytes = 5 * 1024 * 1024
+	}
+	if opts.MaxQueueEntries <= 0 {
+		opts.MaxQueueEntries = 10000
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 5
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 100 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 10 * time.Second
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(err error) {
+			fmt.Fprintf(os.Stderr, "stackdriver: %v\n", err)
+		}
+	}
+
+	w := &APIWriter{
+		opts: opts,
+		logName: fmt.Sprintf("projects/%s/logs/%s",
+			opts.ProjectID, url.PathEscape(opts.LogID)),
+		full:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues a single JSON log entry.
+func (w *APIWriter) Write(p []byte) (int, error) {
+	var payload map[string]any
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return 0, fmt.Errorf("error when decoding log entry: %w", err)
+	}
+	entry := newLogEntry(payload)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	if len(w.pending) >= w.opts.MaxQueueEntries {
+		w.queueFull++
+		w.dropped.Add(1)
+		return 0, ErrQueueFull
+	}
+	w.pending = append(w.pending, entry)
+	w.size += len(p)
+	if len(w.pending) >= w.opts.MaxBatchEntries || w.size >= w.opts.MaxBatchBytes {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends all queued entries. Entries which cannot be sent after all
+// retries are dropped and reported in the returned error.
+func (w *APIWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	entries := w.pending
+	queueFull := w.queueFull
+	w.pending = nil
+	w.size = 0
+	w.queueFull = 0
+	w.mu.Unlock()
+
+	var errs []error
+	if queueFull > 0 {
+		errs = append(errs, fmt.Errorf("error when queueing %d log entries: %w", queueFull, ErrQueueFull))
+	}
+	for len(entries) > 0 {
+		n := w.batchLen(entries)
+		if err := w.send(ctx, entries[:n]); err != nil {
+			w.dropped.Add(int64(n))
+			errs = append(errs, err)
+		}
+		entries = entries[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// Dropped returns the number of entries which were dropped because the queue
+// was full or a request failed after all retries.
+func (w *APIWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Close stops the background flushing and sends the remaining entries.
+func (w *APIWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.done)
+	<-w.stopped
+	return w.Flush(context.Background())
+}
+
+func (w *APIWriter) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.full:
+		case <-w.done:
+			return
+		}
+		if err := w.Flush(context.Background()); err != nil {
+			w.opts.OnError(err)
+		}
+	}
+}
+
+// batchLen returns how many of entries fit in a single request.
+func (w *APIWriter) batchLen(entries []map[string]any) int {
+	size := 0
+	for i, entry := range entries {
+		if i == w.opts.MaxBatchEntries {
+			return i
+		}
+		b, _ := json.Marshal(entry)
+		size += len(b)
+		if i > 0 && size > w.opts.MaxBatchBytes {
+			return i
+		}
+	}
+	return len(entries)
+}
+
+func (w *APIWriter) send(ctx context.Context, entries []map[string]any) error {
+	body, err := json.Marshal(map[string]any{
+		"logName":        w.logName,
+		"resource":       w.opts.Resource,
+		"entries":        entries,
+		"partialSuccess": true,
+	})
+	if err != nil {
+		return fmt.Errorf("error when encoding log entries: %w", err)
+	}
+
+	backoff := w.opts.MinBackoff
+	for attempt := 0; ; attempt++ {
+		w.sendMu.Lock()
+		retry, err := w.post(ctx, body)
+		w.sendMu.Unlock()
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.opts.MaxRetries {
+			return fmt.Errorf("error when writing %d log entries: %w", len(entries), err)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("error when writing %d log entries: %w", len(entries), ctx.Err())
+		}
+		backoff = min(backoff*2, w.opts.MaxBackoff)
+	}
+}
+
+// post sends a single request and reports whether a failure can be retried.
+func (w *APIWriter) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.opts.TokenSource != nil {
+		token, err := w.opts.TokenSource(ctx)
+		if err != nil {
+			return true, fmt.Errorf("error when getting access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := w.opts.HTTPClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	retry := resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode >= 500
+	return retry, err
+}
+
+// Special fields which are moved from the JSON payload to the LogEntry:
+// - https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry
+var logEntryFields = map[string]string{
+	LabelsKey:                               "labels",
+	OperationKey:                            "operation",
+	InsertIDKey:                             "insertId",
+	SpanIDKey:                               "spanId",
+	"logging.googleapis.com/trace":          "trace",
+	"logging.googleapis.com/trace_sampled":  "traceSampled",
+	"logging.googleapis.com/sourceLocation": "sourceLocation",
+	"httpRequest":                           "httpRequest",
+	slog.TimeKey:                            "timestamp",
+}
+
+// newLogEntry converts a structured log entry as written to stdout into a
+// LogEntry of the Cloud Logging API.
+func newLogEntry(payload map[string]any) map[string]any {
+	entry := map[string]any{}
+	for key, field := range logEntryFields {
+		if v, ok := payload[key]; ok {
+			entry[field] = v
+			delete(payload, key)
+		}
+	}
+
+	severity := "DEFAULT"
+	for _, key := range []string{"severity", slog.LevelKey} {
+		if v, ok := payload[key].(string); ok {
+			severity = apiSeverity(v)
+			delete(payload, key)
+			break
+		}
+	}
+	entry["severity"] = severity
+	entry["jsonPayload"] = payload
+	return entry
+}
+
+// apiSeverity maps a level name, as written by ReplaceLogLevel, to a
+// LogSeverity accepted by the Cloud Logging API.
+func apiSeverity(value string) string {
+	level, err := ParseLevel(value)
+	if err != nil {
+		return "DEFAULT"
+	}
+	return levels.Severity(level)
+}
@@ -0,0 +1,118 @@
+package stackdriver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dusted-go/logging/v2/handlers/stackdriver/stackdrivertest"
+)
+
+func TestAPIWriterSendsBatches(t *testing.T) {
+	server := stackdrivertest.NewServer()
+	defer server.Close()
+	server.FailNext(http.StatusServiceUnavailable)
+
+	writer := NewAPIWriter(APIWriterOptions{
+		ProjectID:       "my-project",
+		LogID:           "my-log",
+		Endpoint:        server.Endpoint(),
+		HTTPClient:      server.Client(),
+		TokenSource:     func(context.Context) (string, error) { return "token", nil },
+		MaxBatchEntries: 2,
+		FlushInterval:   time.Hour,
+		MinBackoff:      time.Millisecond,
+		OnError:         func(err error) { t.Errorf("unexpected error: %v", err) },
+	})
+	logger := slog.New(NewHandler(&HandlerOptions{
+		ServiceName: "my-service",
+		Writer:      writer,
+	}))
+
+	logger.Log(context.Background(), NOTICE, "first", Label("env", "test"))
+	logger.Warn("second")
+	logger.Info("third")
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error when closing writer: %v", err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got: %d", len(requests))
+	}
+	if requests[0].LogName != "projects/my-project/logs/my-log" {
+		t.Errorf("unexpected log name: %s", requests[0].LogName)
+	}
+
+	entries := server.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got: %d", len(entries))
+	}
+	wantSeverities := []string{"NOTICE", "WARNING", "INFO"}
+	for i, entry := range entries {
+		if entry["severity"] != wantSeverities[i] {
+			t.Errorf("entry %d severity mismatch: got %v, want %v", i, entry["severity"], wantSeverities[i])
+		}
+		if _, ok := entry["timestamp"]; !ok {
+			t.Errorf("entry %d is missing a timestamp", i)
+		}
+	}
+	if labels, _ := entries[0]["labels"].(map[string]any); labels["env"] != "test" {
+		t.Errorf("expected labels on the LogEntry, got %v", entries[0]["labels"])
+	}
+	if payload, _ := entries[0]["jsonPayload"].(map[string]any); payload["message"] != "first" {
+		t.Errorf("expected message in the JSON payload, got %v", entries[0]["jsonPayload"])
+	}
+}
+
+func TestAPIWriterRejectsWritesAfterClose(t *testing.T) {
+	server := stackdrivertest.NewServer()
+	defer server.Close()
+
+	writer := NewAPIWriter(APIWriterOptions{
+		Endpoint:   server.Endpoint(),
+		HTTPClient: server.Client(),
+	})
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error when closing writer: %v", err)
+	}
+	if _, err := writer.Write([]byte(`{"message":"late"}`)); err != ErrWriterClosed {
+		t.Errorf("expected ErrWriterClosed, got: %v", err)
+	}
+}
+
+func TestAPIWriterDropsEntries(t *testing.T) {
+	server := stackdrivertest.NewServer()
+	defer server.Close()
+	server.FailNext(http.StatusInternalServerError)
+
+	writer := NewAPIWriter(APIWriterOptions{
+		Endpoint:        server.Endpoint(),
+		HTTPClient:      server.Client(),
+		MaxQueueEntries: 2,
+		FlushInterval:   time.Hour,
+		MaxRetries:      -1,
+	})
+	defer writer.Close()
+
+	for i, want := range []error{nil, nil, ErrQueueFull} {
+		if _, err := writer.Write([]byte(`{"message":"entry"}`)); !errors.Is(err, want) {
+			t.Errorf("write %d: expected %v, got: %v", i, want, err)
+		}
+	}
+	err := writer.Flush(context.Background())
+	if !errors.Is(err, ErrQueueFull) || !strings.Contains(err.Error(), "error when writing 2 log entries") {
+		t.Errorf("expected queue and write errors, got: %v", err)
+	}
+	if got := writer.Dropped(); got != 3 {
+		t.Errorf("expected 3 dropped entries, got: %d", got)
+	}
+
+	if _, err := writer.Write([]byte(`{"message":"entry"}`)); err != nil {
+		t.Errorf("expected the queue to accept entries after a flush, got: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package stackdriver
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/dusted-go/logging/v2/slogctx"
+)
+
+// Special fields which are recognised by Cloud Logging when they appear at the
+// top level of a structured log entry:
+// - https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+const (
+	LabelsKey    = "logging.googleapis.com/labels"
+	OperationKey = "logging.googleapis.com/operation"
+	InsertIDKey  = "logging.googleapis.com/insertId"
+	SpanIDKey    = "logging.googleapis.com/spanId"
+)
+
+// Label returns an attribute which sets a single Cloud Logging label.
+// Labels added through multiple calls to With are merged into one map.
+func Label(key, value string) slog.Attr {
+	return slog.Group(LabelsKey, slog.String(key, value))
+}
+
+// Labels returns an attribute which sets multiple Cloud Logging labels.
+func Labels(labels map[string]string) slog.Attr {
+	attrs := make([]slog.Attr, 0, len(labels))
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		attrs = append(attrs, slog.String(k, labels[k]))
+	}
+	return slog.Attr{Key: LabelsKey, Value: slog.GroupValue(attrs...)}
+}
+
+// OperationEntry returns an attribute which links a log entry to an operation.
+func OperationEntry(id, producer string, first, last bool) slog.Attr {
+	return slog.Attr{Key: OperationKey, Value: operationValue(id, producer, first, last)}
+}
+
+// InsertID returns an attribute which sets the unique identifier of a log entry.
+func InsertID(id string) slog.Attr {
+	return slog.String(InsertIDKey, id)
+}
+
+// SpanID returns an attribute which sets the span ID of a log entry.
+func SpanID(id string) slog.Attr {
+	return slog.String(SpanIDKey, id)
+}
+
+func operationValue(id, producer string, first, last bool) slog.Value {
+	attrs := []slog.Attr{
+		slog.String("id", id),
+		slog.String("producer", producer),
+	}
+	if first {
+		attrs = append(attrs, slog.Bool("first", true))
+	}
+	if last {
+		attrs = append(attrs, slog.Bool("last", true))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// WithLabels returns a copy of ctx whose logger adds the given labels to every entry.
+func WithLabels(ctx context.Context, labels map[string]string) context.Context {
+	logger := slogctx.GetLogger(ctx).With(Labels(labels))
+	return slogctx.WithLogger(ctx, logger)
+}
+
+// Operation groups related log entries in Cloud Logging.
+//
+// The first entry written through the operation's logger is marked as the
+// first entry of the operation and End writes the last entry.
+type Operation struct {
+	id       string
+	producer string
+	logger   *slog.Logger
+	started  atomic.Bool
+}
+
+type operationContextKey int
+
+const lastEntryKey operationContextKey = 0
+
+// StartOperation starts a new operation and returns a copy of ctx which
+// carries a logger that links every entry to the operation.
+func StartOperation(ctx context.Context, id, producer string) (context.Context, *Operation) {
+	op := &Operation{id: id, producer: producer}
+	op.logger = slogctx.GetLogger(ctx).With(slog.Any(OperationKey, op))
+	return slogctx.WithLogger(ctx, op.logger), op
+}
+
+// Logger returns the logger which links every entry to the operation.
+func (o *Operation) Logger() *slog.Logger {
+	return o.logger
+}
+
+// End writes the last entry of the operation.
+func (o *Operation) End(ctx context.Context, msg string, args ...any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithValue(ctx, lastEntryKey, true)
+	if !o.logger.Enabled(ctx, slog.LevelInfo) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, msg, slogctx.CallerPC(0))
+	r.Add(args...)
+	_ = o.logger.Handler().Handle(ctx, r)
+}
+
+// LogValue implements slog.LogValuer so that the operation is still written
+// when the logger is not backed by a stackdriver Handler.
+func (o *Operation) LogValue() slog.Value {
+	return operationValue(o.id, o.producer, false, false)
+}
+
+// entry returns the operation field for the next entry written in ctx.
+func (o *Operation) entry(ctx context.Context) slog.Value {
+	first := o.started.CompareAndSwap(false, true)
+	last := ctx.Value(lastEntryKey) != nil
+	return operationValue(o.id, o.producer, first, last)
+}
+
+// specialFields holds the Cloud Logging special fields which must be written
+// at the top level of an entry, regardless of any open groups.
+type specialFields struct {
+	labels map[string]string
+	fields []slog.Attr
+}
+
+func (s specialFields) clone() specialFields {
+	return specialFields{
+		labels: maps.Clone(s.labels),
+		fields: append([]slog.Attr(nil), s.fields...),
+	}
+}
+
+// add records a if it is a special field and reports whether it was one.
+func (s *specialFields) add(a slog.Attr) bool {
+	switch a.Key {
+	case LabelsKey:
+		if a.Value.Kind() != slog.KindGroup {
+			return false
+		}
+		if s.labels == nil {
+			s.labels = make(map[string]string)
+		}
+		for _, l := range a.Value.Group() {
+			s.labels[l.Key] = l.Value.Resolve().String()
+		}
+		return true
+	case OperationKey, InsertIDKey, SpanIDKey:
+		for i := range s.fields {
+			if s.fields[i].Key == a.Key {
+				s.fields[i] = a
+				return true
+			}
+		}
+		s.fields = append(s.fields, a)
+		return true
+	}
+	return false
+}
+
+// attrs returns the special fields as attributes for an entry written in ctx.
+func (s specialFields) attrs(ctx context.Context) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(s.fields)+1)
+	if len(s.labels) > 0 {
+		attrs = append(attrs, Labels(s.labels))
+	}
+	for _, a := range s.fields {
+		if a.Key == OperationKey && a.Value.Kind() == slog.KindLogValuer {
+			if op, ok := a.Value.LogValuer().(*Operation); ok {
+				a.Value = op.entry(ctx)
+			}
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
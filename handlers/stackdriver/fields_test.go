@@ -0,0 +1,78 @@
+package stackdriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/dusted-go/logging/v2/slogctx"
+)
+
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(NewHandler(&HandlerOptions{Writer: buf}))
+}
+
+func decodeEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("invalid JSON entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLabelsAreMerged(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := newTestLogger(buf).
+		With(Label("env", "prod")).
+		WithGroup("request").
+		With(Labels(map[string]string{"tenant": "acme", "env": "dev"}), slog.String("id", "123"))
+
+	logger.Info("testing labels", Label("job", "import"))
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got: %d", len(entries))
+	}
+	want := map[string]any{"env": "dev", "tenant": "acme", "job": "import"}
+	if got := entries[0][LabelsKey]; !reflect.DeepEqual(got, want) {
+		t.Errorf("labels mismatch: got %v, want %v", got, want)
+	}
+	wantGroup := map[string]any{"id": "123"}
+	if got := entries[0]["request"]; !reflect.DeepEqual(got, wantGroup) {
+		t.Errorf("group mismatch: got %v, want %v", got, wantGroup)
+	}
+}
+
+func TestOperationMarksFirstAndLast(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := slogctx.WithLogger(context.Background(), newTestLogger(buf))
+
+	ctx, op := StartOperation(ctx, "op-1", "importer")
+	slogctx.GetLogger(ctx).InfoContext(ctx, "started")
+	op.Logger().WithGroup("step").Info("working")
+	op.End(ctx, "finished")
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got: %d", len(entries))
+	}
+	want := []map[string]any{
+		{"id": "op-1", "producer": "importer", "first": true},
+		{"id": "op-1", "producer": "importer"},
+		{"id": "op-1", "producer": "importer", "last": true},
+	}
+	for i, entry := range entries {
+		if got := entry[OperationKey]; !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("entry %d operation mismatch: got %v, want %v", i, got, want[i])
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+
+	"github.com/dusted-go/logging/v2/internal/groupattrs"
 )
 
 const (
@@ -12,7 +14,12 @@ const (
 )
 
 type Handler struct {
-	h slog.Handler
+	h       slog.Handler
+	special specialFields
+	// nested holds the groups and attributes which were added after the first
+	// call to WithGroup. They are applied to each record in Handle so that
+	// special fields can still be written at the top level.
+	nested groupattrs.Nested
 }
 
 func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
@@ -20,18 +27,70 @@ func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
 }
 
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &Handler{h: h.h.WithAttrs(attrs)}
+	return h.withAttrs(attrs)
+}
+
+func (h *Handler) withAttrs(attrs []slog.Attr) *Handler {
+	h2 := h.clone()
+	var rest []slog.Attr
+	for _, a := range attrs {
+		if !h2.special.add(a) {
+			rest = append(rest, a)
+		}
+	}
+	if len(rest) == 0 {
+		return h2
+	}
+	if h2.nested.Empty() {
+		h2.h = h2.h.WithAttrs(rest)
+	} else {
+		h2.nested = h2.nested.WithAttrs(rest)
+	}
+	return h2
 }
 
 func (h *Handler) WithGroup(name string) slog.Handler {
-	return &Handler{h: h.h.WithGroup(name)}
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.nested = h2.nested.WithGroup(name)
+	return h2
+}
+
+func (h *Handler) clone() *Handler {
+	return &Handler{
+		h:       h.h,
+		special: h.special.clone(),
+		nested:  h.nested,
+	}
 }
 
 func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	special := h.special
+	cloned := false
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == LabelsKey || a.Key == OperationKey || a.Key == InsertIDKey || a.Key == SpanIDKey {
+			if !cloned {
+				special = special.clone()
+				cloned = true
+			}
+			if special.add(a) {
+				return true
+			}
+		}
+		attrs = append(attrs, a)
+		return true
+	})
+
+	var top []slog.Attr
 	if r.Level >= slog.LevelError {
-		r.AddAttrs(slog.String(attrErrorTypeKey, attrErrorTypeVal))
+		top = append(top, slog.String(attrErrorTypeKey, attrErrorTypeVal))
 	}
-	err := h.h.Handle(ctx, r)
+	top = append(top, special.attrs(ctx)...)
+
+	err := h.h.Handle(ctx, h.nested.Record(r, top, attrs))
 	if err != nil {
 		return fmt.Errorf("error when calling nested handler's Handle: %w", err)
 	}
@@ -2,77 +2,52 @@ package stackdriver
 
 import (
 	"log/slog"
-	"strconv"
-	"strings"
+
+	"github.com/dusted-go/logging/v2/levels"
 )
 
 // The slog package provides four log levels by default,
 // and each one is associated with an integer value:
 // DEBUG (-4), INFO (0), WARN (4), and ERROR (8).
+// The remaining Cloud Logging severities are registered in the levels package.
 const (
-	DEBUG     = slog.LevelDebug
-	INFO      = slog.LevelInfo
-	NOTICE    = slog.Level(2)
-	WARNING   = slog.LevelWarn
-	ERROR     = slog.LevelError
-	CRITICAL  = slog.Level(10)
-	ALERT     = slog.Level(12)
-	EMERGENCY = slog.Level(14)
+	DEBUG     = levels.DEBUG
+	INFO      = levels.INFO
+	NOTICE    = levels.NOTICE
+	WARNING   = levels.WARN
+	ERROR     = levels.ERROR
+	CRITICAL  = levels.CRITICAL
+	ALERT     = levels.ALERT
+	EMERGENCY = levels.EMERGENCY
 )
 
-var levelNames = map[slog.Leveler]string{
-	NOTICE:    "NOTICE",
-	CRITICAL:  "CRITICAL",
-	ALERT:     "ALERT",
-	EMERGENCY: "EMERGENCY",
-}
-
-func ReplaceLogLevel(_ []string, a slog.Attr) slog.Attr {
-	if a.Key == slog.LevelKey {
-		level := a.Value.Any().(slog.Level)
-		value, ok := levelNames[level]
-		if !ok {
-			value = level.String()
-		}
-		a.Key = "severity"
-		a.Value = slog.StringValue(value)
+// ReplaceLogLevel replaces the built-in level attribute with the Cloud Logging
+// severity of the level, as registered in the levels package.
+func ReplaceLogLevel(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 || a.Key != slog.LevelKey {
 		return a
 	}
+	if level, ok := a.Value.Any().(slog.Level); ok {
+		a.Key = "severity"
+		a.Value = slog.StringValue(levels.Severity(level))
+	}
 	return a
 }
 
+// ParseLogLevel parses a level name, Cloud Logging severity or integer.
+// It returns INFO for unknown input.
+//
+// Deprecated: use ParseLevel, which reports unknown levels.
 func ParseLogLevel(value string) slog.Leveler {
-	if len(value) > 0 {
-		v := strings.ToLower(strings.Trim(value, " "))
-
-		if v == "debug" {
-			return DEBUG
-		}
-		if v == "info" {
-			return INFO
-		}
-		if v == "notice" {
-			return NOTICE
-		}
-		if v == "warning" {
-			return WARNING
-		}
-		if v == "error" {
-			return ERROR
-		}
-		if v == "critical" {
-			return CRITICAL
-		}
-		if v == "alert" {
-			return ALERT
-		}
-		if v == "emergency" {
-			return EMERGENCY
-		}
-
-		if i, err := strconv.Atoi(value); err == nil {
-			return slog.Level(i)
-		}
+	level, err := ParseLevel(value)
+	if err != nil {
+		return INFO
 	}
-	return INFO
+	return level
+}
+
+// ParseLevel parses a level name, Cloud Logging severity or integer.
+// It returns an error for unknown input.
+func ParseLevel(value string) (slog.Level, error) {
+	return levels.Parse(value)
 }
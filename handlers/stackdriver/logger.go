@@ -0,0 +1,88 @@
+package stackdriver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dusted-go/logging/v2/slogctx"
+)
+
+// Logger wraps a *slog.Logger with methods for the Cloud Logging severities
+// which have no equivalent in slog. It works with any slog.Handler.
+type Logger struct {
+	*slog.Logger
+}
+
+// NewLogger returns a Logger which writes to l.
+// If l is nil, slog.Default() is used.
+func NewLogger(l *slog.Logger) *Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &Logger{Logger: l}
+}
+
+// With returns a Logger that includes the given attributes in each output.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{Logger: l.Logger.With(args...)}
+}
+
+// WithGroup returns a Logger that starts a group.
+func (l *Logger) WithGroup(name string) *Logger {
+	return &Logger{Logger: l.Logger.WithGroup(name)}
+}
+
+// Notice logs at NOTICE.
+func (l *Logger) Notice(msg string, args ...any) {
+	l.log(context.Background(), NOTICE, msg, args...)
+}
+
+// NoticeContext logs at NOTICE with the given context.
+func (l *Logger) NoticeContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, NOTICE, msg, args...)
+}
+
+// Critical logs at CRITICAL.
+func (l *Logger) Critical(msg string, args ...any) {
+	l.log(context.Background(), CRITICAL, msg, args...)
+}
+
+// CriticalContext logs at CRITICAL with the given context.
+func (l *Logger) CriticalContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, CRITICAL, msg, args...)
+}
+
+// Alert logs at ALERT.
+func (l *Logger) Alert(msg string, args ...any) {
+	l.log(context.Background(), ALERT, msg, args...)
+}
+
+// AlertContext logs at ALERT with the given context.
+func (l *Logger) AlertContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, ALERT, msg, args...)
+}
+
+// Emergency logs at EMERGENCY.
+func (l *Logger) Emergency(msg string, args ...any) {
+	l.log(context.Background(), EMERGENCY, msg, args...)
+}
+
+// EmergencyContext logs at EMERGENCY with the given context.
+func (l *Logger) EmergencyContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, EMERGENCY, msg, args...)
+}
+
+// log records the caller of the exported method as the source of the entry,
+// skipping any functions marked by slogctx.Helper.
+func (l *Logger) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), level, msg, slogctx.CallerPC(1))
+	r.Add(args...)
+	_ = l.Handler().Handle(ctx, r)
+}
@@ -0,0 +1,74 @@
+package stackdriver
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLoggerSeverities(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger(slog.New(NewHandler(&HandlerOptions{
+		Writer:    buf,
+		MinLevel:  NOTICE,
+		AddSource: true,
+	})))
+
+	ctx := context.Background()
+	logger.Notice("notice")
+	logger.NoticeContext(ctx, "notice with context")
+	logger.Critical("critical")
+	logger.CriticalContext(ctx, "critical with context")
+	logger.Alert("alert")
+	logger.AlertContext(ctx, "alert with context")
+	logger.With("a", 1).Emergency("emergency")
+	logger.EmergencyContext(ctx, "emergency with context")
+
+	want := []struct {
+		message  string
+		severity string
+	}{
+		{"notice", "NOTICE"},
+		{"notice with context", "NOTICE"},
+		{"critical", "CRITICAL"},
+		{"critical with context", "CRITICAL"},
+		{"alert", "ALERT"},
+		{"alert with context", "ALERT"},
+		{"emergency", "EMERGENCY"},
+		{"emergency with context", "EMERGENCY"},
+	}
+	entries := decodeEntries(t, buf)
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got: %d", len(want), len(entries))
+	}
+	for i, entry := range entries {
+		if entry["message"] != want[i].message || entry["severity"] != want[i].severity {
+			t.Errorf("entry %d = %v %v, want %s %s",
+				i, entry["message"], entry["severity"], want[i].message, want[i].severity)
+		}
+		source, _ := entry["logging.googleapis.com/sourceLocation"].(map[string]any)
+		file, _ := source["file"].(string)
+		if !strings.HasSuffix(file, "logger_test.go") {
+			t.Errorf("entry %d source file = %q, want the caller", i, file)
+		}
+	}
+	if entries[6]["a"] != 1.0 {
+		t.Errorf("expected attribute from With, got %v", entries[6])
+	}
+}
+
+func TestLoggerLevelGating(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger(slog.New(NewHandler(&HandlerOptions{Writer: buf, MinLevel: ALERT})))
+
+	logger.Notice("notice")
+	logger.Critical("critical")
+	logger.Alert("alert")
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 || entries[0]["severity"] != "ALERT" {
+		t.Errorf("expected only the ALERT entry, got: %v", entries)
+	}
+}
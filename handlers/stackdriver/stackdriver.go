@@ -2,12 +2,12 @@ package stackdriver
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
 
-	"github.com/dusted-go/logging/v2/slogctx"
-	"github.com/google/uuid"
+	"github.com/dusted-go/logging/v2/middlewares/httplogger"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -16,12 +16,31 @@ type HandlerOptions struct {
 	ServiceVersion string
 	MinLevel       slog.Leveler
 	AddSource      bool
+	// Writer is the destination of the log entries.
+	// If nil, os.Stdout is used.
+	Writer io.Writer
+	// ReplaceAttr is called on each attribute before it is mapped to
+	// the fields expected by Cloud Logging.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+	// Attrs are added to every log entry.
+	Attrs []slog.Attr
+	// MaxEntrySize is the largest size in bytes of an encoded log entry.
+	// Larger entries are truncated. If zero, DefaultMaxEntrySize is used.
+	// A negative value disables the limit.
+	MaxEntrySize int
 }
 
 type MiddlewareOptions struct {
 	GCPProjectID   string
 	AddTrace       bool
 	AddHTTPRequest bool
+	// EchoRequestID determines whether to set the X-Request-ID header of
+	// the response to the request ID.
+	EchoRequestID bool
+	// Config holds further options of the httplogger middleware, such as
+	// LogRequest, TrustedProxies or ExcludeHeaders. Its BaseHandler and
+	// Schema are set by Logging, and the options above take precedence.
+	Config httplogger.Config
 }
 
 // Official Google Cloud Logging docs for structured logs:
@@ -46,21 +65,61 @@ func stackdriverAttrs(groups []string, a slog.Attr) slog.Attr {
 	return ReplaceLogLevel(groups, a)
 }
 
+// ReplaceAttr returns a function which maps slog's built-in attributes and
+// errors to the fields expected by Cloud Logging. It can be used as the
+// ReplaceAttr option of any slog handler.
+//
+// If next is not nil it is called on each attribute before the mapping.
+func ReplaceAttr(
+	next func([]string, slog.Attr) slog.Attr,
+) func([]string, slog.Attr) slog.Attr {
+	if next == nil {
+		return stackdriverAttrs
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		a = next(groups, a)
+		if a.Equal(slog.Attr{}) {
+			return a
+		}
+		return stackdriverAttrs(groups, a)
+	}
+}
+
+// Wrap returns a Handler which writes the Cloud Logging special fields and
+// error reporting type of each record to h.
+//
+// h should map its attributes with ReplaceAttr to produce entries which
+// are understood by Cloud Logging.
+func Wrap(h slog.Handler) *Handler {
+	return &Handler{h: h}
+}
+
 func NewHandler(opts *HandlerOptions) *Handler {
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+	writer := opts.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+	switch {
+	case opts.MaxEntrySize == 0:
+		writer = LimitEntrySize(writer, DefaultMaxEntrySize)
+	case opts.MaxEntrySize > 0:
+		writer = LimitEntrySize(writer, opts.MaxEntrySize)
+	}
 	handlerOpts := &slog.HandlerOptions{
 		Level:       opts.MinLevel,
 		AddSource:   opts.AddSource,
-		ReplaceAttr: stackdriverAttrs,
+		ReplaceAttr: ReplaceAttr(opts.ReplaceAttr),
 	}
-	handler := slog.
-		NewJSONHandler(os.Stdout, handlerOpts).
-		WithAttrs([]slog.Attr{
-			slog.Group("serviceContext",
-				slog.String("service", opts.ServiceName),
-				slog.String("version", opts.ServiceVersion),
-			),
-		})
-	return &Handler{h: handler}
+	attrs := append([]slog.Attr{
+		slog.Group("serviceContext",
+			slog.String("service", opts.ServiceName),
+			slog.String("version", opts.ServiceVersion),
+		),
+	}, opts.Attrs...)
+	return Wrap(slog.NewJSONHandler(writer, handlerOpts)).withAttrs(attrs)
 }
 
 func getTraceAttrs(googleProjectID string, span trace.SpanContext) (slog.Attr, slog.Attr, slog.Attr) {
@@ -69,7 +128,7 @@ func getTraceAttrs(googleProjectID string, span trace.SpanContext) (slog.Attr, s
 		googleProjectID,
 		span.TraceID().String())
 	return slog.String("logging.googleapis.com/trace", googleTraceID),
-		slog.String("logging.googleapis.com/spanId", span.SpanID().String()),
+		SpanID(span.SpanID().String()),
 		slog.Bool("logging.googleapis.com/trace_sampled", span.IsSampled())
 }
 
@@ -86,47 +145,24 @@ func WithTrace(
 	return logger
 }
 
+// Logging creates a middleware which adds a request-scoped logger writing
+// Cloud Logging entries to the context. It is httplogger.RequestScoped with
+// the httplogger.SchemaGCP attributes.
 func Logging(
 	hOpts *HandlerOptions,
 	mOpts *MiddlewareOptions,
 ) func(http.Handler) http.Handler {
-	handler := NewHandler(hOpts)
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(
-			func(w http.ResponseWriter, r *http.Request) {
-				ctx := r.Context()
-
-				requestID := r.Header.Get("X-Request-ID")
-				if requestID == "" {
-					requestID = uuid.NewString()
-				}
-				reqHandler := handler.WithAttrs(
-					[]slog.Attr{slog.String("requestId", requestID)})
-
-				if mOpts.AddTrace {
-					span := trace.SpanFromContext(ctx).SpanContext()
-					if span.IsValid() {
-						traceID, spanID, sampled := getTraceAttrs(mOpts.GCPProjectID, span)
-						reqHandler = reqHandler.WithAttrs([]slog.Attr{traceID, spanID, sampled})
-					}
-				}
-				if mOpts.AddHTTPRequest {
-					reqHandler = reqHandler.WithAttrs([]slog.Attr{
-						slog.Group("httpRequest",
-							slog.String("requestMethod", r.Method),
-							slog.String("requestUrl", r.URL.String()),
-							slog.String("protocol", r.Proto),
-							slog.String("remoteIp", r.RemoteAddr),
-							slog.String("userAgent", r.UserAgent()),
-							slog.String("referer", r.Referer()),
-						),
-					})
-				}
-				logger := slog.New(reqHandler)
-				ctx = slogctx.WithLogger(ctx, logger)
-				r = r.WithContext(ctx)
-				next.ServeHTTP(w, r)
-			},
-		)
+	if mOpts == nil {
+		mOpts = &MiddlewareOptions{}
+	}
+	cfg := mOpts.Config
+	cfg.BaseHandler = NewHandler(hOpts)
+	cfg.Schema = httplogger.SchemaGCP
+	if mOpts.GCPProjectID != "" {
+		cfg.GCPProjectID = mOpts.GCPProjectID
 	}
+	cfg.AddTrace = cfg.AddTrace || mOpts.AddTrace
+	cfg.AddRequestToLogger = cfg.AddRequestToLogger || mOpts.AddHTTPRequest
+	cfg.EchoRequestID = cfg.EchoRequestID || mOpts.EchoRequestID
+	return httplogger.RequestScoped(cfg)
 }
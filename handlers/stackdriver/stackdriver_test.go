@@ -0,0 +1,121 @@
+package stackdriver
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/dusted-go/logging/v2/middlewares/httplogger"
+	"github.com/dusted-go/logging/v2/slogctx"
+)
+
+func TestNewHandlerIsComposable(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := NewHandler(&HandlerOptions{
+		ServiceName:    "my-service",
+		ServiceVersion: "1.0.0",
+		Writer:         buf,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == "password" {
+				return slog.String(a.Key, "***")
+			}
+			return a
+		},
+		Attrs: []slog.Attr{slog.String("region", "europe-west2")},
+	})
+	logger := slog.New(handler)
+
+	logger.Error("testing handler", "password", "secret", "err", errors.New("boom"))
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got: %d", len(entries))
+	}
+	entry := entries[0]
+	want := map[string]any{
+		"message":  "testing handler",
+		"severity": "ERROR",
+		"password": "***",
+		"region":   "europe-west2",
+		"@type":    attrErrorTypeVal,
+		"serviceContext": map[string]any{
+			"service": "my-service",
+			"version": "1.0.0",
+		},
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(entry[k], v) {
+			t.Errorf("field %s mismatch: got %v, want %v", k, entry[k], v)
+		}
+	}
+	if errField, ok := entry["error"].(map[string]any); !ok || errField["message"] != "boom" {
+		t.Errorf("expected error field with message `boom`, got %v", entry["error"])
+	}
+}
+
+func TestWrapHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	inner := slog.NewJSONHandler(buf, &slog.HandlerOptions{ReplaceAttr: ReplaceAttr(nil)})
+	logger := slog.New(Wrap(inner)).With(Label("env", "test"))
+
+	logger.Info("testing wrap")
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got: %d", len(entries))
+	}
+	if entries[0]["message"] != "testing wrap" || entries[0]["severity"] != "INFO" {
+		t.Errorf("expected mapped message and severity, got %v", entries[0])
+	}
+	if got := entries[0][LabelsKey]; !reflect.DeepEqual(got, map[string]any{"env": "test"}) {
+		t.Errorf("labels mismatch: got %v", got)
+	}
+}
+
+func TestLoggingAddsHTTPRequest(t *testing.T) {
+	buf := &bytes.Buffer{}
+	mw := Logging(&HandlerOptions{Writer: buf}, &MiddlewareOptions{
+		AddHTTPRequest: true,
+		EchoRequestID:  true,
+		Config:         httplogger.Config{LogResponse: true},
+	})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slogctx.GetLogger(r.Context()).Info("handling")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders?page=2&token=secret", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	req.Header.Set("User-Agent", "Go-Test")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Request-ID"); got != "abc-123" {
+		t.Errorf("echoed request ID = %q, want %q", got, "abc-123")
+	}
+	entries := decodeEntries(t, buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got: %d", len(entries))
+	}
+	handling, completed := entries[0], entries[1]
+	// Unlike before Logging used httplogger, the URL is absolute with
+	// redacted query parameters, and the remote IP has no port.
+	want := map[string]any{
+		"requestMethod": "GET",
+		"requestUrl":    "http://example.com/orders?page=2&token=[REDACTED]",
+		"protocol":      "HTTP/1.1",
+		"remoteIp":      "192.0.2.1",
+		"userAgent":     "Go-Test",
+	}
+	if handling["requestId"] != "abc-123" || !reflect.DeepEqual(handling["httpRequest"], want) {
+		t.Errorf("unexpected entry: %v", handling)
+	}
+	httpRequest, _ := completed["httpRequest"].(map[string]any)
+	if completed["severity"] != "WARNING" || httpRequest["status"] != float64(http.StatusTeapot) || httpRequest["latency"] == nil {
+		t.Errorf("unexpected completion entry: %v", completed)
+	}
+}
@@ -0,0 +1,93 @@
+// Package stackdrivertest provides a local stand-in for the Cloud Logging
+// entries:write API, for use with stackdriver.APIWriter in tests and during
+// local development.
+package stackdrivertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// WriteRequest is the body of an entries:write request.
+type WriteRequest struct {
+	LogName        string           `json:"logName"`
+	Resource       map[string]any   `json:"resource"`
+	Entries        []map[string]any `json:"entries"`
+	PartialSuccess bool             `json:"partialSuccess"`
+}
+
+// Server is a fake entries:write endpoint which records every request.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []WriteRequest
+	failures []int
+}
+
+// NewServer starts a new Server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Endpoint returns the URL to use as stackdriver.APIWriterOptions.Endpoint.
+func (s *Server) Endpoint() string {
+	return s.URL + "/v2/entries:write"
+}
+
+// FailNext makes the next requests fail with the given status codes, in order.
+func (s *Server) FailNext(statusCodes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statusCodes...)
+}
+
+// Requests returns the successful requests received so far.
+func (s *Server) Requests() []WriteRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]WriteRequest(nil), s.requests...)
+}
+
+// Entries returns the log entries of all successful requests received so far.
+func (s *Server) Entries() []map[string]any {
+	var entries []map[string]any
+	for _, req := range s.Requests() {
+		entries = append(entries, req.Entries...)
+	}
+	return entries
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v2/entries:write" {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		s.mu.Unlock()
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	s.mu.Unlock()
+
+	var req WriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}"))
+}
@@ -0,0 +1,223 @@
+package stackdriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxEntrySize is the largest log entry accepted by Cloud Logging:
+// - https://cloud.google.com/logging/quotas#log-limits
+const DefaultMaxEntrySize = 256 * 1024
+
+const (
+	truncatedKey         = "truncated"
+	truncatedSuffix      = "..."
+	minTruncatedLength   = 64
+	maxTruncatedFrames   = 8
+	maxTruncateAttempts  = 1000
+	truncatedStackSuffix = "(truncated)"
+)
+
+var errEntryTooLarge = errors.New("stackdriver: entry cannot be truncated to the size limit")
+
+// LimitEntrySize returns a writer which makes sure that each JSON log entry
+// written to w is no larger than limit bytes.
+//
+// Entries which are too large are truncated by shortening the stack and the
+// largest string values. When that is not enough, the largest fields are
+// dropped, except for the message, severity, time and Cloud Logging fields.
+// A "truncated" field with the original size and the number of dropped fields
+// is added to truncated entries.
+//
+// Entries which still do not fit, or which are not a single JSON object, are
+// replaced by an entry with only the severity, time, the start of the message
+// and the "truncated" field. An error is returned if even that does not fit.
+func LimitEntrySize(w io.Writer, limit int) io.Writer {
+	return &sizeLimitWriter{w: w, limit: limit}
+}
+
+type sizeLimitWriter struct {
+	w     io.Writer
+	limit int
+}
+
+func (w *sizeLimitWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.limit {
+		return w.w.Write(p)
+	}
+	entry, err := truncateEntry(p, w.limit)
+	if err != nil {
+		if entry, err = fallbackEntry(p, w.limit); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := w.w.Write(entry); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// truncateEntry shortens the JSON object in p until it fits within limit.
+func truncateEntry(p []byte, limit int) ([]byte, error) {
+	var entry map[string]any
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&entry); err != nil {
+		return nil, err
+	}
+	marker := map[string]any{"originalSize": len(p)}
+	entry[truncatedKey] = marker
+	truncateStacks(entry)
+
+	for range maxTruncateAttempts {
+		out, err := encodeEntry(entry)
+		if err != nil || len(out) <= limit {
+			return out, err
+		}
+
+		var largest string
+		var set func(string)
+		walkStrings(entry, func(s string, setter func(string)) {
+			if len(s) > len(largest) {
+				largest, set = s, setter
+			}
+		})
+		if len(largest) <= minTruncatedLength+len(truncatedSuffix) {
+			if !dropLargestField(entry) {
+				return nil, errEntryTooLarge
+			}
+			dropped, _ := marker["droppedFields"].(int)
+			marker["droppedFields"] = dropped + 1
+			continue
+		}
+		keep := max(len(largest)-(len(out)-limit)-len(truncatedSuffix), minTruncatedLength)
+		set(truncateString(largest, keep))
+	}
+	out, err := encodeEntry(entry)
+	if err == nil && len(out) > limit {
+		return nil, errEntryTooLarge
+	}
+	return out, err
+}
+
+// fallbackEntry returns an entry with only the severity, time and the start
+// of the message of p, for entries which cannot be truncated to limit.
+func fallbackEntry(p []byte, limit int) ([]byte, error) {
+	var entry map[string]any
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&entry); err != nil {
+		entry = map[string]any{"message": string(bytes.TrimSpace(p))}
+	}
+
+	fallback := make(map[string]any, 4)
+	for _, key := range []string{"severity", "time"} {
+		if v, ok := entry[key]; ok {
+			fallback[key] = v
+		}
+	}
+	if msg, ok := entry["message"].(string); ok {
+		fallback["message"] = truncateString(msg, minTruncatedLength)
+	}
+	delete(entry, truncatedKey)
+	fallback[truncatedKey] = map[string]any{
+		"originalSize":  len(p),
+		"droppedFields": len(entry) - len(fallback),
+	}
+
+	out, err := encodeEntry(fallback)
+	if err == nil && len(out) > limit {
+		return nil, errEntryTooLarge
+	}
+	return out, err
+}
+
+// dropLargestField removes the top-level field of entry with the largest JSON
+// encoding, keeping the fields which Cloud Logging needs to show the entry.
+// It reports whether a field was removed.
+func dropLargestField(entry map[string]any) bool {
+	var largest string
+	largestSize := -1
+	for k, v := range entry {
+		switch {
+		case k == "message", k == "severity", k == "time", k == truncatedKey,
+			strings.HasPrefix(k, "logging.googleapis.com/"):
+			continue
+		}
+		b, _ := json.Marshal(v)
+		if size := len(k) + len(b); size > largestSize {
+			largest, largestSize = k, size
+		}
+	}
+	if largestSize < 0 {
+		return false
+	}
+	delete(entry, largest)
+	return true
+}
+
+func encodeEntry(entry map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(entry); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// truncateStacks keeps only the top frames of every stack in v.
+func truncateStacks(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if frames, ok := child.([]any); ok && k == "stack" && len(frames) > maxTruncatedFrames {
+				v[k] = append(frames[:maxTruncatedFrames:maxTruncatedFrames], truncatedStackSuffix)
+				continue
+			}
+			truncateStacks(child)
+		}
+	case []any:
+		for _, child := range v {
+			truncateStacks(child)
+		}
+	}
+}
+
+// walkStrings calls fn for every string value in v with a function which
+// replaces that value.
+func walkStrings(v any, fn func(s string, set func(string))) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if s, ok := child.(string); ok {
+				fn(s, func(n string) { v[k] = n })
+				continue
+			}
+			walkStrings(child, fn)
+		}
+	case []any:
+		for i, child := range v {
+			if s, ok := child.(string); ok {
+				fn(s, func(n string) { v[i] = n })
+				continue
+			}
+			walkStrings(child, fn)
+		}
+	}
+}
+
+// truncateString cuts s to at most n bytes without splitting a UTF-8 sequence.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncatedSuffix
+}
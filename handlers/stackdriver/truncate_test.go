@@ -0,0 +1,142 @@
+package stackdriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestEntriesAreTruncated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(NewHandler(&HandlerOptions{Writer: buf, MaxEntrySize: 2048}))
+
+	logger.Error("testing truncation",
+		"payload", strings.Repeat("é", 4096),
+		"small", "kept",
+		"err", errors.New("boom"),
+	)
+
+	line := buf.Bytes()
+	if len(line) > 2048 {
+		t.Errorf("expected entry of at most 2048 bytes, got: %d", len(line))
+	}
+	if !json.Valid(line) {
+		t.Fatalf("expected valid JSON, got: %s", line)
+	}
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got: %d", len(entries))
+	}
+	entry := entries[0]
+	if entry["small"] != "kept" {
+		t.Errorf("expected small attr to be kept, got %v", entry["small"])
+	}
+	if payload, _ := entry["payload"].(string); !strings.HasSuffix(payload, truncatedSuffix) {
+		t.Errorf("expected payload to be truncated, got %d bytes", len(payload))
+	}
+	marker, ok := entry[truncatedKey].(map[string]any)
+	if !ok || marker["originalSize"].(float64) <= 2048 {
+		t.Errorf("expected truncated marker with original size, got %v", entry[truncatedKey])
+	}
+}
+
+func TestSmallEntriesAreNotTruncated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(NewHandler(&HandlerOptions{Writer: buf}))
+
+	logger.Info("testing truncation", "payload", strings.Repeat("a", 1024))
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got: %d", len(entries))
+	}
+	if _, ok := entries[0][truncatedKey]; ok {
+		t.Errorf("expected entry not to be truncated")
+	}
+}
+
+func TestManySmallFieldsAreDropped(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(NewHandler(&HandlerOptions{Writer: buf, MaxEntrySize: 2048}))
+
+	args := make([]any, 0, 200)
+	for i := range 100 {
+		args = append(args, fmt.Sprintf("field%03d", i), strings.Repeat("x", 40))
+	}
+	logger.Warn("testing truncation", args...)
+
+	line := buf.Bytes()
+	if len(line) > 2048 {
+		t.Errorf("expected entry of at most 2048 bytes, got: %d", len(line))
+	}
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got: %d", len(entries))
+	}
+	entry := entries[0]
+	if entry["message"] != "testing truncation" || entry["severity"] != "WARNING" {
+		t.Errorf("expected message and severity to be kept, got %v", entry)
+	}
+	marker, _ := entry[truncatedKey].(map[string]any)
+	dropped, _ := marker["droppedFields"].(float64)
+	if dropped == 0 {
+		t.Fatalf("expected dropped fields in truncated marker, got %v", entry[truncatedKey])
+	}
+	fields := 0
+	for k := range entry {
+		if strings.HasPrefix(k, "field") {
+			fields++
+		}
+	}
+	if fields+int(dropped) != 100 {
+		t.Errorf("expected %d fields to be kept, got: %d", 100-int(dropped), fields)
+	}
+}
+
+func TestStrippedEntriesFallBack(t *testing.T) {
+	labels := make([]any, 0, 100)
+	for i := range 100 {
+		labels = append(labels, Label(fmt.Sprintf("label%03d", i), strings.Repeat("x", 40)))
+	}
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(NewHandler(&HandlerOptions{Writer: buf, MaxEntrySize: 1024}))
+	logger.Error(strings.Repeat("message ", 100), labels...)
+
+	line := buf.Bytes()
+	if len(line) > 1024 {
+		t.Fatalf("expected entry of at most 1024 bytes, got: %d", len(line))
+	}
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got: %d", len(entries))
+	}
+	entry := entries[0]
+	if entry["severity"] != "ERROR" || entry["time"] == nil {
+		t.Errorf("expected severity and time to be kept, got %v", entry)
+	}
+	if msg, _ := entry["message"].(string); !strings.HasPrefix(msg, "message ") || !strings.HasSuffix(msg, truncatedSuffix) {
+		t.Errorf("expected truncated message, got %q", msg)
+	}
+	if _, ok := entry[LabelsKey]; ok {
+		t.Errorf("expected labels to be dropped, got %v", entry[LabelsKey])
+	}
+	if marker, _ := entry[truncatedKey].(map[string]any); marker["droppedFields"] == nil {
+		t.Errorf("expected truncated marker, got %v", entry[truncatedKey])
+	}
+}
+
+func TestEntriesTooLargeForFallbackFail(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := LimitEntrySize(buf, 16)
+	if _, err := w.Write([]byte(`{"severity":"INFO","message":"` + strings.Repeat("a", 100) + `"}` + "\n")); err == nil {
+		t.Errorf("expected an error")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing to be written, got: %s", buf)
+	}
+}
@@ -0,0 +1,54 @@
+// Package groupattrs keeps the groups and attributes which are added to a
+// slog.Handler after its first WithGroup call, so that a wrapping handler can
+// still add attributes at the top level of each record.
+package groupattrs
+
+import "log/slog"
+
+// Nested holds groups and attributes in the order in which they were added.
+// The zero value holds nothing.
+type Nested struct {
+	goas []groupOrAttrs
+}
+
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// Empty reports whether no group has been opened.
+func (n Nested) Empty() bool {
+	return len(n.goas) == 0
+}
+
+// WithGroup returns a copy of n with the group name opened.
+func (n Nested) WithGroup(name string) Nested {
+	return Nested{goas: append(n.goas[:len(n.goas):len(n.goas)], groupOrAttrs{group: name})}
+}
+
+// WithAttrs returns a copy of n with attrs added to the innermost group.
+func (n Nested) WithAttrs(attrs []slog.Attr) Nested {
+	return Nested{goas: append(n.goas[:len(n.goas):len(n.goas)], groupOrAttrs{attrs: attrs})}
+}
+
+// Apply wraps attrs in the groups and attributes held by n.
+func (n Nested) Apply(attrs []slog.Attr) []slog.Attr {
+	for i := len(n.goas) - 1; i >= 0; i-- {
+		goa := n.goas[i]
+		if goa.group != "" {
+			attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+		} else {
+			attrs = append(append([]slog.Attr(nil), goa.attrs...), attrs...)
+		}
+	}
+	return attrs
+}
+
+// Record returns a copy of r with the top attributes first, followed by the
+// attributes of r wrapped in the groups held by n.
+func (n Nested) Record(r slog.Record, top []slog.Attr, attrs []slog.Attr) slog.Record {
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(top...)
+	nr.AddAttrs(n.Apply(attrs)...)
+	return nr
+}
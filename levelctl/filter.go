@@ -0,0 +1,157 @@
+package levelctl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/dusted-go/logging/v2/levels"
+	"github.com/dusted-go/logging/v2/slogctx"
+)
+
+// NameLeveler reports the minimum level of the logger with the given name.
+// An empty name is used for loggers without a name.
+type NameLeveler interface {
+	LevelFor(name string) slog.Level
+}
+
+// Spec is a static NameLeveler parsed from a list of levels per logger name,
+// such as "info,billing=debug,billing.http=warn".
+type Spec struct {
+	level slog.Level
+	rules map[string]slog.Level
+	cache sync.Map
+}
+
+// ParseSpec parses a comma separated list of "name=level" rules and an
+// optional default level without a name. Levels are parsed by levels.Parse.
+// If no default level is given, INFO is used.
+func ParseSpec(spec string) (*Spec, error) {
+	s := &Spec{level: slog.LevelInfo, rules: map[string]slog.Level{}}
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		name, value, found := strings.Cut(rule, "=")
+		if !found {
+			name, value = "", rule
+		}
+		level, err := levels.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level spec %q: %w", rule, err)
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			s.level = level
+		} else {
+			s.rules[name] = level
+		}
+	}
+	return s, nil
+}
+
+// LevelFor returns the level of the most specific rule which matches name.
+func (s *Spec) LevelFor(name string) slog.Level {
+	if level, ok := s.cache.Load(name); ok {
+		return level.(slog.Level)
+	}
+	level, ok := lookupName(s.rules, name)
+	if !ok {
+		level = s.level
+	}
+	s.cache.Store(name, level)
+	return level
+}
+
+// LevelFor returns the override of the most specific name which matches
+// name, or the current level if there is none.
+func (c *Controller) LevelFor(name string) slog.Level {
+	if level, ok := lookupName(*c.overrides.Load(), name); ok {
+		return level
+	}
+	return c.Level()
+}
+
+// lookupName looks up name and then each of its parents, such as
+// "billing.http" and "billing" for "billing.http.client".
+func lookupName(rules map[string]slog.Level, name string) (slog.Level, bool) {
+	for name != "" {
+		if level, ok := rules[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return 0, false
+}
+
+// FilterHandler is a slog.Handler which enables records based on the name of
+// the logger, as set by slogctx.Named.
+//
+// The level of the wrapped handler is ignored, so that verbose loggers can be
+// enabled without lowering the level of the wrapped handler.
+type FilterHandler struct {
+	h       slog.Handler
+	levels  NameLeveler
+	name    string
+	written bool
+	grouped bool
+}
+
+// NewFilter returns a FilterHandler which writes enabled records to h.
+func NewFilter(h slog.Handler, levels NameLeveler) *FilterHandler {
+	return &FilterHandler{h: h, levels: levels}
+}
+
+func (f *FilterHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= f.levels.LevelFor(f.name)
+}
+
+func (f *FilterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	f2 := *f
+	var rest []slog.Attr
+	for _, a := range attrs {
+		if a.Key == slogctx.NameKey {
+			f2.name = a.Value.String()
+			// The name is written by Handle, unless a group has been opened
+			// since, in which case it is only used for the level.
+			if !f2.grouped {
+				f2.written = false
+				continue
+			}
+		}
+		rest = append(rest, a)
+	}
+	if len(rest) > 0 {
+		f2.h = f2.h.WithAttrs(rest)
+	}
+	return &f2
+}
+
+func (f *FilterHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return f
+	}
+	f2 := *f
+	if f2.name != "" && !f2.written {
+		f2.h = f2.h.WithAttrs([]slog.Attr{slog.String(slogctx.NameKey, f2.name)})
+		f2.written = true
+	}
+	f2.h = f2.h.WithGroup(name)
+	f2.grouped = true
+	return &f2
+}
+
+func (f *FilterHandler) Handle(ctx context.Context, r slog.Record) error {
+	if f.name != "" && !f.written {
+		r = r.Clone()
+		r.AddAttrs(slog.String(slogctx.NameKey, f.name))
+	}
+	return f.h.Handle(ctx, r)
+}
@@ -0,0 +1,74 @@
+package levelctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/dusted-go/logging/v2/handlers/stackdriver"
+	"github.com/dusted-go/logging/v2/slogctx"
+)
+
+func TestSpecLevelFor(t *testing.T) {
+	spec, err := ParseSpec("warn, billing=debug, billing.http=error")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want slog.Level
+	}{
+		{"", slog.LevelWarn},
+		{"auth", slog.LevelWarn},
+		{"billing", slog.LevelDebug},
+		{"billing.invoices", slog.LevelDebug},
+		{"billing.http", slog.LevelError},
+		{"billing.http.client", slog.LevelError},
+		{"billingx", slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		if got := spec.LevelFor(tt.name); got != tt.want {
+			t.Errorf("LevelFor(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := ParseSpec("info,billing=verbose"); err == nil {
+		t.Errorf("expected error for unknown level")
+	}
+}
+
+func TestFilterHandlerWithNamedLoggers(t *testing.T) {
+	spec, err := ParseSpec("info,billing.invoices=debug")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	handler := stackdriver.NewHandler(&stackdriver.HandlerOptions{
+		MinLevel: slog.LevelInfo,
+		Writer:   buf,
+	})
+	ctx := slogctx.WithLogger(context.Background(), slog.New(NewFilter(handler, spec)))
+
+	ctx, billing := slogctx.Named(ctx, "billing")
+	_, invoices := slogctx.Named(ctx, "invoices")
+	billing.Debug("hidden")
+	invoices.Debug("visible")
+	invoices.WithGroup("req").Debug("grouped", "id", 1)
+
+	var names []string
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("invalid JSON entry: %v", err)
+		}
+		names = append(names, entry["message"].(string)+":"+entry[slogctx.NameKey].(string))
+	}
+	want := []string{"visible:billing.invoices", "grouped:billing.invoices"}
+	if len(names) != len(want) || names[0] != want[0] || names[1] != want[1] {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+}
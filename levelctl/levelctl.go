@@ -0,0 +1,282 @@
+// Package levelctl controls the minimum log level at runtime, over HTTP or
+// with signals, without redeploying.
+//
+// A Controller implements slog.Leveler, so it can be passed directly as the
+// level of any handler, such as stackdriver.HandlerOptions.MinLevel or the
+// slog.HandlerOptions of prettylog.
+package levelctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dusted-go/logging/v2/levels"
+)
+
+// Controller holds the current log level and optional per-logger overrides.
+type Controller struct {
+	level     slog.LevelVar
+	overrides atomic.Pointer[map[string]slog.Level]
+
+	mu      sync.Mutex
+	base    slog.Level
+	expires time.Time
+	timer   *time.Timer
+	named   map[string]*override
+}
+
+type override struct {
+	level   slog.Level
+	expires time.Time
+	timer   *time.Timer
+}
+
+// New creates a Controller with the given level.
+func New(level slog.Level) *Controller {
+	c := &Controller{base: level, named: map[string]*override{}}
+	c.level.Set(level)
+	c.overrides.Store(&map[string]slog.Level{})
+	return c
+}
+
+// Level implements slog.Leveler and returns the current level.
+func (c *Controller) Level() slog.Level {
+	return c.level.Level()
+}
+
+// Set changes the current level. If ttl is positive, the level reverts to
+// the previous permanent level once ttl has elapsed.
+func (c *Controller) Set(level slog.Level, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.expires = time.Time{}
+	c.level.Set(level)
+	if ttl <= 0 {
+		c.base = level
+		return
+	}
+	c.expires = time.Now().Add(ttl)
+	var timer *time.Timer
+	timer = time.AfterFunc(ttl, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.timer != timer {
+			return
+		}
+		c.timer = nil
+		c.expires = time.Time{}
+		c.level.Set(c.base)
+	})
+	c.timer = timer
+}
+
+// step moves the current level to the next lower or higher registered level.
+func (c *Controller) step(lower bool) {
+	current := c.Level()
+	all := levels.All()
+	next := current
+	if lower {
+		for _, l := range all {
+			if l.Level < current {
+				next = l.Level
+			}
+		}
+	} else {
+		for i := len(all) - 1; i >= 0; i-- {
+			if all[i].Level > current {
+				next = all[i].Level
+			}
+		}
+	}
+	c.Set(next, 0)
+}
+
+// SetName overrides the level of the logger with the given name. If ttl is
+// positive, the override is removed once ttl has elapsed.
+func (c *Controller) SetName(name string, level slog.Level, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopName(name)
+	o := &override{level: level}
+	if ttl > 0 {
+		o.expires = time.Now().Add(ttl)
+		o.timer = time.AfterFunc(ttl, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.named[name] != o {
+				return
+			}
+			delete(c.named, name)
+			c.publish()
+		})
+	}
+	c.named[name] = o
+	c.publish()
+}
+
+// ResetName removes the level override of the logger with the given name.
+func (c *Controller) ResetName(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopName(name)
+	delete(c.named, name)
+	c.publish()
+}
+
+// Override returns the level override of the logger with the given name.
+func (c *Controller) Override(name string) (slog.Level, bool) {
+	level, ok := (*c.overrides.Load())[name]
+	return level, ok
+}
+
+// Overrides returns a copy of the current per-logger overrides.
+func (c *Controller) Overrides() map[string]slog.Level {
+	return maps.Clone(*c.overrides.Load())
+}
+
+// ForName returns a slog.Leveler which reports the level of the logger with
+// the given name, as returned by LevelFor.
+func (c *Controller) ForName(name string) slog.Leveler {
+	return namedLeveler{c: c, name: name}
+}
+
+type namedLeveler struct {
+	c    *Controller
+	name string
+}
+
+func (l namedLeveler) Level() slog.Level {
+	return l.c.LevelFor(l.name)
+}
+
+func (c *Controller) stopName(name string) {
+	if o, ok := c.named[name]; ok && o.timer != nil {
+		o.timer.Stop()
+	}
+}
+
+// publish stores a snapshot of the overrides for lock-free reads.
+// It must be called with c.mu held.
+func (c *Controller) publish() {
+	snapshot := make(map[string]slog.Level, len(c.named))
+	for name, o := range c.named {
+		snapshot[name] = o.level
+	}
+	c.overrides.Store(&snapshot)
+}
+
+// State is the JSON representation of a Controller served over HTTP.
+type State struct {
+	Level   string                 `json:"level"`
+	Expires *time.Time             `json:"expires,omitempty"`
+	Loggers map[string]LoggerState `json:"loggers,omitempty"`
+}
+
+// LoggerState is the JSON representation of a per-logger override.
+type LoggerState struct {
+	Level   string     `json:"level"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// State returns the current level and overrides.
+func (c *Controller) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := State{Level: levels.Name(c.level.Level())}
+	if !c.expires.IsZero() {
+		expires := c.expires
+		s.Expires = &expires
+	}
+	if len(c.named) > 0 {
+		s.Loggers = make(map[string]LoggerState, len(c.named))
+		for name, o := range c.named {
+			ls := LoggerState{Level: levels.Name(o.level)}
+			if !o.expires.IsZero() {
+				expires := o.expires
+				ls.Expires = &expires
+			}
+			s.Loggers[name] = ls
+		}
+	}
+	return s
+}
+
+// updateRequest is the body of a PUT request.
+type updateRequest struct {
+	Level  string `json:"level"`
+	Logger string `json:"logger"`
+	TTL    string `json:"ttl"`
+}
+
+// ServeHTTP serves the current state on GET and changes it on PUT.
+//
+// A PUT request takes a JSON body or query parameters with a "level", which
+// is parsed by levels.Parse, an optional "logger" name and an optional "ttl",
+// such as "10m". A DELETE request with a "logger" query parameter removes
+// that override.
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		if err := c.update(w, r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		name := r.URL.Query().Get("logger")
+		if name == "" {
+			http.Error(w, "missing logger", http.StatusBadRequest)
+			return
+		}
+		c.ResetName(name)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c.State())
+}
+
+func (c *Controller) update(w http.ResponseWriter, r *http.Request) error {
+	var req updateRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.ContentLength != 0 && mediaType == "application/json" {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			return fmt.Errorf("invalid body: %w", err)
+		}
+	} else {
+		q := r.URL.Query()
+		req = updateRequest{Level: q.Get("level"), Logger: q.Get("logger"), TTL: q.Get("ttl")}
+	}
+
+	level, err := levels.Parse(req.Level)
+	if err != nil {
+		return err
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			return fmt.Errorf("invalid ttl: %w", err)
+		}
+	}
+
+	if req.Logger != "" {
+		c.SetName(req.Logger, level, ttl)
+	} else {
+		c.Set(level, ttl)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package levelctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dusted-go/logging/v2/handlers/stackdriver"
+)
+
+func TestControllerAsHandlerLevel(t *testing.T) {
+	c := New(slog.LevelInfo)
+	buf := &bytes.Buffer{}
+	logger := slog.New(stackdriver.NewHandler(&stackdriver.HandlerOptions{
+		MinLevel: c,
+		Writer:   buf,
+	}))
+
+	logger.Debug("hidden")
+	c.Set(slog.LevelDebug, 0)
+	logger.Debug("visible")
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "visible") {
+		t.Errorf("expected only the second entry to be written, got: %s", buf.String())
+	}
+}
+
+func TestControllerTTL(t *testing.T) {
+	c := New(slog.LevelInfo)
+	c.Set(slog.LevelDebug, 10*time.Millisecond)
+	c.SetName("billing", slog.LevelDebug, 10*time.Millisecond)
+	if c.Level() != slog.LevelDebug || c.ForName("billing").Level() != slog.LevelDebug {
+		t.Fatalf("expected DEBUG before the TTL elapsed")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for c.Level() != slog.LevelInfo || len(c.Overrides()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected level and overrides to revert, got %v and %v", c.Level(), c.Overrides())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	c := New(slog.LevelInfo)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		contentType string
+		wantStatus  int
+		wantState   State
+	}{
+		{
+			name:       "get",
+			method:     http.MethodGet,
+			target:     "/",
+			wantStatus: http.StatusOK,
+			wantState:  State{Level: "INFO"},
+		},
+		{
+			name:       "put query",
+			method:     http.MethodPut,
+			target:     "/?level=notice",
+			wantStatus: http.StatusOK,
+			wantState:  State{Level: "NOTICE"},
+		},
+		{
+			name:       "put logger",
+			method:     http.MethodPut,
+			target:     "/",
+			body:       `{"level":"debug","logger":"billing"}`,
+			wantStatus: http.StatusOK,
+			wantState: State{Level: "NOTICE", Loggers: map[string]LoggerState{
+				"billing": {Level: "DEBUG"},
+			}},
+		},
+		{
+			name:        "put json with charset",
+			method:      http.MethodPut,
+			target:      "/",
+			body:        `{"level":"warning"}`,
+			contentType: "application/json; charset=utf-8",
+			wantStatus:  http.StatusOK,
+			wantState: State{Level: "WARN", Loggers: map[string]LoggerState{
+				"billing": {Level: "DEBUG"},
+			}},
+		},
+		{
+			name:       "delete logger",
+			method:     http.MethodDelete,
+			target:     "/?logger=billing",
+			wantStatus: http.StatusOK,
+			wantState:  State{Level: "WARN"},
+		},
+		{
+			name:       "unknown level",
+			method:     http.MethodPut,
+			target:     "/?level=verbose",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			} else if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			c.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got State
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if got.Level != tt.wantState.Level || len(got.Loggers) != len(tt.wantState.Loggers) {
+				t.Errorf("state = %+v, want %+v", got, tt.wantState)
+			}
+			for name, want := range tt.wantState.Loggers {
+				if got.Loggers[name].Level != want.Level {
+					t.Errorf("logger %s level = %s, want %s", name, got.Loggers[name].Level, want.Level)
+				}
+			}
+		})
+	}
+}
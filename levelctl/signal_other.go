@@ -0,0 +1,8 @@
+//go:build !unix
+
+package levelctl
+
+import "context"
+
+// NotifySignals does nothing on platforms without SIGUSR1 and SIGUSR2.
+func (c *Controller) NotifySignals(ctx context.Context) {}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package levelctl
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// NotifySignals changes the level when the process receives SIGUSR1 or
+// SIGUSR2, until ctx is done. SIGUSR1 lowers the level to the next registered
+// level, making the logs more verbose, and SIGUSR2 raises it again.
+func (c *Controller) NotifySignals(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				c.step(sig == syscall.SIGUSR1)
+			}
+		}
+	}()
+}
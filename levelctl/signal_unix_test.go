@@ -0,0 +1,40 @@
+//go:build unix
+
+package levelctl
+
+import (
+	"context"
+	"log/slog"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dusted-go/logging/v2/levels"
+)
+
+func TestNotifySignals(t *testing.T) {
+	c := New(slog.LevelInfo)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.NotifySignals(ctx)
+
+	for _, tt := range []struct {
+		sig  syscall.Signal
+		want slog.Level
+	}{
+		{syscall.SIGUSR1, slog.LevelDebug},
+		{syscall.SIGUSR2, slog.LevelInfo},
+		{syscall.SIGUSR2, levels.NOTICE},
+	} {
+		if err := syscall.Kill(syscall.Getpid(), tt.sig); err != nil {
+			t.Fatalf("error when sending %v: %v", tt.sig, err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for c.Level() != tt.want {
+			if time.Now().After(deadline) {
+				t.Fatalf("level after %v = %v, want %v", tt.sig, c.Level(), tt.want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
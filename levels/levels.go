@@ -0,0 +1,222 @@
+// Package levels is a registry of log levels which is shared by all handlers
+// in this module, so that custom levels have the same name, color and
+// Cloud Logging severity everywhere.
+package levels
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Levels which are registered by default. The slog package provides four
+// levels, and each one is associated with an integer value:
+// DEBUG (-4), INFO (0), WARN (4), and ERROR (8).
+const (
+	TRACE     = slog.Level(-8)
+	DEBUG     = slog.LevelDebug
+	INFO      = slog.LevelInfo
+	NOTICE    = slog.Level(2)
+	WARN      = slog.LevelWarn
+	ERROR     = slog.LevelError
+	CRITICAL  = slog.Level(10)
+	ALERT     = slog.Level(12)
+	EMERGENCY = slog.Level(14)
+	FATAL     = slog.Level(16)
+)
+
+// ANSI color codes for Level.Color.
+const (
+	Black        = 30
+	Red          = 31
+	Green        = 32
+	Yellow       = 33
+	Blue         = 34
+	Magenta      = 35
+	Cyan         = 36
+	LightGray    = 37
+	DarkGray     = 90
+	LightRed     = 91
+	LightGreen   = 92
+	LightYellow  = 93
+	LightBlue    = 94
+	LightMagenta = 95
+	LightCyan    = 96
+	White        = 97
+)
+
+// Level describes a registered log level.
+type Level struct {
+	// Level is the numeric value of the level.
+	Level slog.Level
+	// Name is the name which is written by handlers and accepted by Parse.
+	Name string
+	// Color is the ANSI color code used by colorized handlers. If zero, the
+	// color of the range which contains the level is used, see Color.
+	Color int
+	// Severity is the Cloud Logging severity of the level:
+	// https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#logseverity
+	Severity string
+}
+
+var (
+	mu         sync.RWMutex
+	registered = []Level{
+		{Level: TRACE, Name: "TRACE", Color: LightGray, Severity: "DEBUG"},
+		{Level: DEBUG, Name: "DEBUG", Color: LightGray, Severity: "DEBUG"},
+		{Level: INFO, Name: "INFO", Color: Cyan, Severity: "INFO"},
+		{Level: NOTICE, Name: "NOTICE", Color: LightBlue, Severity: "NOTICE"},
+		{Level: WARN, Name: "WARN", Color: LightYellow, Severity: "WARNING"},
+		{Level: ERROR, Name: "ERROR", Color: LightRed, Severity: "ERROR"},
+		{Level: CRITICAL, Name: "CRITICAL", Color: LightMagenta, Severity: "CRITICAL"},
+		{Level: ALERT, Name: "ALERT", Color: LightMagenta, Severity: "ALERT"},
+		{Level: EMERGENCY, Name: "EMERGENCY", Color: LightMagenta, Severity: "EMERGENCY"},
+		{Level: FATAL, Name: "FATAL", Color: LightMagenta, Severity: "EMERGENCY"},
+	}
+)
+
+// Register adds a level to the registry, replacing any level with the same
+// numeric value. It is usually called from an init function.
+func Register(l Level) {
+	l.Name = strings.ToUpper(l.Name)
+	if l.Severity == "" {
+		l.Severity = Get(l.Level).Severity
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	i, found := slices.BinarySearchFunc(registered, l.Level, compareLevel)
+	if found {
+		registered[i] = l
+		return
+	}
+	registered = slices.Insert(registered, i, l)
+}
+
+// All returns the registered levels in ascending order.
+func All() []Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	return slices.Clone(registered)
+}
+
+// Lookup returns the registered level with the exact numeric value of level.
+func Lookup(level slog.Level) (Level, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	i, found := slices.BinarySearchFunc(registered, level, compareLevel)
+	if !found {
+		return Level{}, false
+	}
+	return registered[i], true
+}
+
+// Get returns the registered level which is closest to, but not above,
+// level. Levels below the lowest registered level resolve to that level.
+func Get(level slog.Level) Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	i, found := slices.BinarySearchFunc(registered, level, compareLevel)
+	if !found && i > 0 {
+		i--
+	}
+	return registered[i]
+}
+
+// Name returns the name of level. Levels which are not registered are named
+// after the closest registered level with an offset, such as "INFO+1".
+func Name(level slog.Level) string {
+	l := Get(level)
+	if l.Level == level {
+		return l.Name
+	}
+	return fmt.Sprintf("%s%+d", l.Name, level-l.Level)
+}
+
+// Color returns the ANSI color code of level. Registered levels use their own
+// color. Other levels use the color of the range which contains them:
+// light gray up to DEBUG, cyan up to INFO, light blue below WARN, light yellow
+// below ERROR, light red up to ERROR+1 and light magenta above.
+func Color(level slog.Level) int {
+	if l, ok := Lookup(level); ok && l.Color != 0 {
+		return l.Color
+	}
+	switch {
+	case level <= DEBUG:
+		return LightGray
+	case level <= INFO:
+		return Cyan
+	case level < WARN:
+		return LightBlue
+	case level < ERROR:
+		return LightYellow
+	case level <= ERROR+1:
+		return LightRed
+	default:
+		return LightMagenta
+	}
+}
+
+// Severity returns the Cloud Logging severity of level.
+func Severity(level slog.Level) string {
+	return Get(level).Severity
+}
+
+// Parse parses a level from a registered name, a Cloud Logging severity,
+// a name with an offset, such as "INFO+1", or an integer. Names are case
+// insensitive.
+func Parse(value string) (slog.Level, error) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	if v == "" {
+		return 0, fmt.Errorf("levels: empty level")
+	}
+	if i, err := strconv.Atoi(v); err == nil {
+		return slog.Level(i), nil
+	}
+
+	name, offset := v, 0
+	if i := strings.LastIndexAny(v, "+-"); i > 0 {
+		n, err := strconv.Atoi(v[i:])
+		if err != nil {
+			return 0, fmt.Errorf("levels: invalid level offset %q: %w", value, err)
+		}
+		name, offset = v[:i], n
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, l := range registered {
+		if l.Name == name {
+			return l.Level + slog.Level(offset), nil
+		}
+	}
+	for _, l := range registered {
+		if l.Severity == name {
+			return l.Level + slog.Level(offset), nil
+		}
+	}
+	if name == "WARNING" {
+		return WARN + slog.Level(offset), nil
+	}
+	return 0, fmt.Errorf("levels: unknown level %q", value)
+}
+
+// ReplaceAttr replaces the value of the built-in level attribute with the
+// registered name of the level. It can be used as the ReplaceAttr option of
+// any slog handler.
+func ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 || a.Key != slog.LevelKey {
+		return a
+	}
+	if level, ok := a.Value.Any().(slog.Level); ok {
+		a.Value = slog.StringValue(Name(level))
+	}
+	return a
+}
+
+func compareLevel(l Level, level slog.Level) int {
+	return int(l.Level) - int(level)
+}
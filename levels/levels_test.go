@@ -0,0 +1,114 @@
+package levels
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  string
+	}{
+		{TRACE, "TRACE"},
+		{TRACE - 2, "TRACE-2"},
+		{slog.LevelInfo, "INFO"},
+		{slog.LevelInfo + 1, "INFO+1"},
+		{NOTICE, "NOTICE"},
+		{CRITICAL, "CRITICAL"},
+		{FATAL + 4, "FATAL+4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := Name(tt.level); got != tt.want {
+				t.Errorf("Name(%d) = %q, want %q", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{input: "debug", want: DEBUG},
+		{input: " Notice ", want: NOTICE},
+		{input: "warning", want: WARN},
+		{input: "WARN", want: WARN},
+		{input: "critical", want: CRITICAL},
+		{input: "INFO+1", want: INFO + 1},
+		{input: "error-2", want: ERROR - 2},
+		{input: "-4", want: DEBUG},
+		{input: "12", want: ALERT},
+		{input: "", wantErr: true},
+		{input: "verbose", wantErr: true},
+		{input: "INFO+x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Parse(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColor(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  int
+	}{
+		{TRACE, LightGray},
+		{DEBUG - 1, LightGray},
+		{DEBUG + 1, Cyan},
+		{INFO, Cyan},
+		{INFO + 1, LightBlue},
+		{NOTICE, LightBlue},
+		{WARN + 1, LightYellow},
+		{ERROR, LightRed},
+		{ERROR + 1, LightRed},
+		{CRITICAL, LightMagenta},
+		{FATAL + 4, LightMagenta},
+	}
+
+	for _, tt := range tests {
+		t.Run(Name(tt.level), func(t *testing.T) {
+			if got := Color(tt.level); got != tt.want {
+				t.Errorf("Color(%d) = %d, want %d", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	saved := All()
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		registered = saved
+	})
+
+	verbose := slog.Level(-6)
+	Register(Level{Level: verbose, Name: "verbose", Color: DarkGray})
+
+	if got := Name(verbose); got != "VERBOSE" {
+		t.Errorf("Name(%d) = %q, want %q", verbose, got, "VERBOSE")
+	}
+	if got := Severity(verbose); got != "DEBUG" {
+		t.Errorf("Severity(%d) = %q, want %q", verbose, got, "DEBUG")
+	}
+	if got, err := Parse("verbose"); err != nil || got != verbose {
+		t.Errorf("Parse(%q) = %d, %v, want %d", "verbose", got, err, verbose)
+	}
+	if got := Color(verbose); got != DarkGray {
+		t.Errorf("Color(%d) = %d, want %d", verbose, got, DarkGray)
+	}
+}
@@ -0,0 +1,214 @@
+package httplogger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Access log formats of the Apache HTTP Server.
+// More info: https://httpd.apache.org/docs/current/logs.html#accesslog
+const (
+	// CommonLogFormat is the NCSA Common Log Format.
+	CommonLogFormat = `%h %l %u %t "%r" %>s %b`
+	// CombinedLogFormat is the NCSA Combined Log Format, which adds the
+	// referer and user agent to CommonLogFormat.
+	CombinedLogFormat = CommonLogFormat + ` "%{Referer}i" "%{User-Agent}i"`
+)
+
+// accessLogTimeFormat is the time format of %t.
+const accessLogTimeFormat = "[02/Jan/2006:15:04:05 -0700]"
+
+// accessLogger writes access log lines in an Apache log format.
+//
+// The supported directives are %h and %a (client address), %l (always "-"),
+// %u (basic auth user), %t (start time), %r (request line), %s and %>s
+// (status), %b and %B (response body size), %D (duration in microseconds),
+// %T (duration in seconds), %m (method), %U (path), %q (query), %H
+// (protocol), %v (server host), %{Name}i (request header), %{Name}o
+// (response header) and %%. Other directives are written verbatim.
+type accessLogger struct {
+	mu         sync.Mutex
+	w          io.Writer
+	directives []accessDirective
+}
+
+// accessDirective is either literal text or a directive with an optional
+// argument.
+type accessDirective struct {
+	literal string
+	verb    byte
+	arg     string
+}
+
+func newAccessLogger(w io.Writer, format string) *accessLogger {
+	if format == "" {
+		format = CommonLogFormat
+	}
+	return &accessLogger{w: w, directives: parseAccessLogFormat(format)}
+}
+
+func parseAccessLogFormat(format string) []accessDirective {
+	var directives []accessDirective
+	for format != "" {
+		i := strings.IndexByte(format, '%')
+		if i < 0 {
+			directives = append(directives, accessDirective{literal: format})
+			break
+		}
+		if i > 0 {
+			directives = append(directives, accessDirective{literal: format[:i]})
+		}
+		start := format[i:]
+		format = format[i+1:]
+
+		var d accessDirective
+		if strings.HasPrefix(format, "{") {
+			end := strings.IndexByte(format, '}')
+			if end < 0 {
+				directives = append(directives, accessDirective{literal: start})
+				break
+			}
+			d.arg = format[1:end]
+			format = format[end+1:]
+		}
+		// The final status is the only one this middleware knows.
+		format = strings.TrimPrefix(format, ">")
+		if format == "" {
+			directives = append(directives, accessDirective{literal: start})
+			break
+		}
+		d.verb = format[0]
+		format = format[1:]
+		directives = append(directives, d)
+	}
+	return directives
+}
+
+// log writes the access log line of a completed request.
+func (l *accessLogger) log(
+	r *http.Request,
+	rw *responseWriter,
+	req *requestLog,
+	start time.Time,
+	duration time.Duration,
+) {
+	var buf bytes.Buffer
+	redact := req.redact
+	for _, d := range l.directives {
+		if d.verb == 0 {
+			buf.WriteString(d.literal)
+			continue
+		}
+		switch d.verb {
+		case 'h', 'a':
+			buf.WriteString(orDash(req.clientInfo().address))
+		case 'l':
+			buf.WriteByte('-')
+		case 'u':
+			user, _, _ := r.BasicAuth()
+			writeEscaped(&buf, orDash(user))
+		case 't':
+			buf.WriteString(start.Format(accessLogTimeFormat))
+		case 'r':
+			// Log the request target as sent by the client.
+			uri := r.RequestURI
+			if uri == "" {
+				uri = r.URL.RequestURI()
+			}
+			if path, query, ok := strings.Cut(uri, "?"); ok {
+				uri = path + "?" + redact.rawQuery(query)
+			}
+			writeEscaped(&buf, r.Method+" "+uri+" "+r.Proto)
+		case 's':
+			if status := rw.Status(); status != 0 {
+				buf.WriteString(strconv.Itoa(status))
+			} else {
+				buf.WriteByte('-')
+			}
+		case 'b':
+			if rw.bytes == 0 {
+				buf.WriteByte('-')
+			} else {
+				buf.WriteString(strconv.FormatInt(rw.bytes, 10))
+			}
+		case 'B':
+			buf.WriteString(strconv.FormatInt(rw.bytes, 10))
+		case 'D':
+			buf.WriteString(strconv.FormatInt(duration.Microseconds(), 10))
+		case 'T':
+			buf.WriteString(strconv.FormatInt(int64(duration.Seconds()), 10))
+		case 'm':
+			buf.WriteString(r.Method)
+		case 'U':
+			writeEscaped(&buf, r.URL.EscapedPath())
+		case 'q':
+			if query := redact.rawQuery(r.URL.RawQuery); query != "" {
+				writeEscaped(&buf, "?"+query)
+			}
+		case 'H':
+			buf.WriteString(r.Proto)
+		case 'v':
+			host, _ := splitHostPort(r.Host)
+			writeEscaped(&buf, orDash(host))
+		case 'i':
+			values := r.Header.Values(d.arg)
+			if len(values) == 0 {
+				buf.WriteByte('-')
+				continue
+			}
+			writeEscaped(&buf, redact.header(strings.ToLower(d.arg), values))
+		case 'o':
+			values := rw.Header().Values(d.arg)
+			if len(values) == 0 {
+				buf.WriteByte('-')
+				continue
+			}
+			writeEscaped(&buf, redact.header(strings.ToLower(d.arg), values))
+		case '%':
+			buf.WriteByte('%')
+		default:
+			buf.WriteByte('%')
+			if d.arg != "" {
+				buf.WriteString("{" + d.arg + "}")
+			}
+			buf.WriteByte(d.verb)
+		}
+	}
+	buf.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(buf.Bytes())
+}
+
+// writeEscaped writes s with quotes, backslashes and non-printable bytes
+// escaped like the Apache HTTP Server does.
+func writeEscaped(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			buf.WriteString(`\x`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xf])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,76 @@
+package httplogger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"regexp"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		cfg    Config
+		want   string
+	}{
+		{
+			name: "Common",
+			want: `^203\.0\.113\.7 - ann \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /orders\?token=\[REDACTED\]&page=2&q=a\+b HTTP/1\.1" 201 5\n$`,
+		},
+		{
+			name:   "Combined",
+			format: CombinedLogFormat,
+			want:   `^203\.0\.113\.7 - ann \[.+\] "GET /orders\?token=\[REDACTED\]&page=2&q=a\+b HTTP/1\.1" 201 5 "https://example\.com/" "curl/8\.0 \\"quoted\\""\n$`,
+		},
+		{
+			name:   "Custom",
+			format: `%a %m %U%q %>s %B %{Content-Type}o %{X-Missing}i %D %% %z`,
+			want:   `^203\.0\.113\.7 GET /orders\?token=\[REDACTED\]&page=2&q=a\+b 201 5 text/plain - \d+ % %z\n$`,
+		},
+		{
+			name:   "Redacted Response Header",
+			format: `%{Set-Cookie}o`,
+			want:   `^session=\[REDACTED\]\n$`,
+		},
+		{
+			name: "Untrusted Proxy",
+			cfg:  Config{TrustedProxies: []netip.Prefix{}},
+			want: `^192\.0\.2\.1 `,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			cfg := tt.cfg
+			cfg.BaseHandler = slog.NewJSONHandler(io.Discard, nil)
+			cfg.AccessLog = buf
+			cfg.AccessLogFormat = tt.format
+			if cfg.TrustedProxies == nil {
+				cfg.TrustedHops = 1
+			}
+			handler := RequestScoped(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.Header().Set("Set-Cookie", "session=s3cr3t; Path=/; HttpOnly")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = io.WriteString(w, "hello")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/orders?token=secret&page=2&q=a+b", nil)
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			req.Header.Set("Referer", "https://example.com/")
+			req.Header.Set("User-Agent", `curl/8.0 "quoted"`)
+			req.SetBasicAuth("ann", "password")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !regexp.MustCompile(tt.want).MatchString(buf.String()) {
+				t.Errorf("access log = %q, want match for %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package httplogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultMaxBodySize is the number of bytes captured per body when
+// Config.MaxBodySize is zero.
+const DefaultMaxBodySize = 4 << 10
+
+// DefaultBodyContentTypes are the media types of the bodies captured when
+// Config.BodyContentTypes is nil.
+var DefaultBodyContentTypes = []string{
+	"application/json",
+	"application/*+json",
+	"application/x-www-form-urlencoded",
+	"text/*",
+}
+
+// DefaultRedactedBodyFields are the JSON object keys and form fields
+// redacted in captured bodies when Config.RedactBodyFields is nil.
+var DefaultRedactedBodyFields = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"client_secret",
+	"id_token",
+	"password",
+	"refresh_token",
+	"secret",
+	"token",
+}
+
+// bodyCapture records the first limit bytes written to it.
+type bodyCapture struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func newBodyCapture(cfg Config) *bodyCapture {
+	limit := cfg.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+	return &bodyCapture{limit: limit}
+}
+
+// Write never fails so that it can be used with io.TeeReader.
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := c.limit - c.buf.Len(); len(p) > room {
+		p = p[:room]
+		c.truncated = true
+	}
+	c.buf.Write(p)
+	return n, nil
+}
+
+// captureBodies sets up the capture of the request and response bodies as
+// configured. The bodies are copied while the handler reads and writes them.
+func captureBodies(r *http.Request, rw *responseWriter, body *bodyCounter, cfg Config) {
+	if !cfg.LogRequestBody && !cfg.LogResponseBody {
+		return
+	}
+	if cfg.BodySampleRate != nil && rand.Float64() >= *cfg.BodySampleRate {
+		return
+	}
+	if cfg.LogRequestBody && body != nil && capturesContentType(cfg, r.Header.Get("Content-Type")) {
+		body.capture = newBodyCapture(cfg)
+	}
+	if cfg.LogResponseBody {
+		// The content type of the response is only known once it completes.
+		rw.capture = newBodyCapture(cfg)
+	}
+}
+
+// bodyAttributes returns the captured bodies as attributes of the
+// completion record.
+func bodyAttributes(r *http.Request, rw *responseWriter, body *bodyCounter, cfg Config) []slog.Attr {
+	var attrs []slog.Attr
+	if body != nil && body.capture != nil {
+		attrs = append(attrs, bodyAttrs(
+			"http.request.body", r.Header.Get("Content-Type"), body.capture, cfg)...)
+	}
+	if rw.capture != nil && capturesContentType(cfg, rw.Header().Get("Content-Type")) {
+		attrs = append(attrs, bodyAttrs(
+			"http.response.body", rw.Header().Get("Content-Type"), rw.capture, cfg)...)
+	}
+	return attrs
+}
+
+func bodyAttrs(key, contentType string, c *bodyCapture, cfg Config) []slog.Attr {
+	if c.buf.Len() == 0 {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String(key, string(redactBody(contentType, c, cfg))),
+		slog.Bool(key+".truncated", c.truncated),
+	}
+}
+
+// redactBody redacts the fields of a JSON or form body and then applies
+// Config.RedactBody. A truncated JSON body keeps its complete tokens, with
+// the fields redacted, and a JSON body which cannot be parsed otherwise is
+// redacted as a whole.
+func redactBody(contentType string, c *bodyCapture, cfg Config) []byte {
+	body := c.buf.Bytes()
+	fields := cfg.RedactBodyFields
+	if fields == nil {
+		fields = DefaultRedactedBodyFields
+	}
+	mediaType := parseMediaType(contentType)
+	if len(fields) > 0 {
+		rd := redactor{params: lowerSet(fields), key: hashKey(cfg)}
+		switch {
+		case (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) && c.truncated:
+			body = redactJSONPrefix(body, rd)
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			body = redactJSON(body, rd)
+		case mediaType == "application/x-www-form-urlencoded":
+			if c.truncated {
+				// Drop the last field, whose value may be cut off.
+				body = body[:max(bytes.LastIndexByte(body, '&'), 0)]
+			}
+			if values, err := url.ParseQuery(string(body)); err == nil {
+				body = []byte(rd.query(values))
+			} else {
+				body = []byte(rd.value(string(body)))
+			}
+		}
+	}
+	if cfg.RedactBody != nil {
+		body = cfg.RedactBody(contentType, body)
+	}
+	return body
+}
+
+func redactJSON(body []byte, rd redactor) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return []byte(rd.value(string(body)))
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redactJSONValue(v, rd)); err != nil {
+		return []byte(rd.value(string(body)))
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactJSONPrefix redacts the fields of a truncated JSON body token by token.
+// It stops at the first incomplete token, so the result is a prefix of the
+// redacted body.
+func redactJSONPrefix(body []byte, rd redactor) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	write := func(v any) {
+		_ = enc.Encode(v)
+		buf.Truncate(buf.Len() - 1) // Encode appends a newline.
+	}
+
+	// Each level counts its tokens. In objects, keys have even counts.
+	type level struct {
+		object bool
+		n      int
+	}
+	var stack []level
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			buf.WriteByte(byte(d))
+			if len(stack) > 0 {
+				stack[len(stack)-1].n++
+			}
+			continue
+		}
+
+		var top *level
+		if len(stack) > 0 {
+			top = &stack[len(stack)-1]
+			switch {
+			case top.object && top.n%2 == 1:
+				buf.WriteByte(':')
+			case top.n > 0:
+				buf.WriteByte(',')
+			}
+		}
+
+		if d, ok := tok.(json.Delim); ok {
+			buf.WriteByte(byte(d))
+			stack = append(stack, level{object: d == '{'})
+			continue
+		}
+		write(tok)
+		if top == nil {
+			continue
+		}
+		top.n++
+
+		key, ok := tok.(string)
+		if !ok || !top.object || top.n%2 == 0 {
+			continue
+		}
+		if _, ok := rd.params[strings.ToLower(key)]; !ok {
+			continue
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			break
+		}
+		var compact bytes.Buffer
+		_ = json.Compact(&compact, raw)
+		buf.WriteByte(':')
+		write(rd.value(compact.String()))
+		top.n++
+	}
+	return buf.Bytes()
+}
+
+func redactJSONValue(v any, rd redactor) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if _, ok := rd.params[strings.ToLower(k)]; ok {
+				raw, _ := json.Marshal(field)
+				v[k] = rd.value(string(raw))
+				continue
+			}
+			v[k] = redactJSONValue(field, rd)
+		}
+	case []any:
+		for i, elem := range v {
+			v[i] = redactJSONValue(elem, rd)
+		}
+	}
+	return v
+}
+
+// capturesContentType reports whether bodies of contentType are captured.
+func capturesContentType(cfg Config, contentType string) bool {
+	patterns := cfg.BodyContentTypes
+	if patterns == nil {
+		patterns = DefaultBodyContentTypes
+	}
+	mediaType := parseMediaType(contentType)
+	if mediaType == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if matchMediaType(strings.ToLower(pattern), mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMediaType matches a media type against a pattern such as
+// "application/json", "text/*" or "application/*+json".
+func matchMediaType(pattern, mediaType string) bool {
+	if pattern == mediaType {
+		return true
+	}
+	typ, suffix, ok := strings.Cut(pattern, "/*")
+	if !ok {
+		return false
+	}
+	return strings.HasPrefix(mediaType, typ+"/") && strings.HasSuffix(mediaType, suffix)
+}
+
+func parseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
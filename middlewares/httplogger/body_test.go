@@ -0,0 +1,191 @@
+package httplogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyCapture(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		contentType  string
+		requestBody  string
+		responseType string
+		responseBody string
+		want         map[string]any
+		notWant      []string
+	}{
+		{
+			name:         "JSON With Redacted Fields",
+			cfg:          Config{LogRequestBody: true, LogResponseBody: true},
+			contentType:  "application/json",
+			requestBody:  `{"user":"ann","password":"hunter2","nested":[{"token":"t"}]}`,
+			responseType: "application/json; charset=utf-8",
+			responseBody: `{"id":1}`,
+			want: map[string]any{
+				"http.request.body":            `{"nested":[{"token":"[REDACTED]"}],"password":"[REDACTED]","user":"ann"}`,
+				"http.request.body.truncated":  false,
+				"http.response.body":           `{"id":1}`,
+				"http.response.body.truncated": false,
+			},
+		},
+		{
+			name:        "Truncated JSON Keeps Redacted Prefix",
+			cfg:         Config{LogRequestBody: true, MaxBodySize: 80},
+			contentType: "application/json",
+			requestBody: `{"user": "ann", "password": "hunter2", "items": [1, 2, {"token": "t"}], "note": "cut off here"}`,
+			want: map[string]any{
+				"http.request.body":           `{"user":"ann","password":"[REDACTED]","items":[1,2,{"token":"[REDACTED]"}],"note"`,
+				"http.request.body.truncated": true,
+			},
+		},
+		{
+			name:        "Truncated JSON Drops Cut Off Secret",
+			cfg:         Config{LogRequestBody: true, MaxBodySize: 20},
+			contentType: "application/json",
+			requestBody: `{"a":1,"password":"hunter2"}`,
+			want: map[string]any{
+				"http.request.body":           `{"a":1,"password"`,
+				"http.request.body.truncated": true,
+			},
+		},
+		{
+			name:        "Invalid JSON Is Redacted As A Whole",
+			cfg:         Config{LogRequestBody: true},
+			contentType: "application/json",
+			requestBody: `{"password":hunter2}`,
+			want: map[string]any{
+				"http.request.body":           Redacted,
+				"http.request.body.truncated": false,
+			},
+		},
+		{
+			name:        "Truncated Form Drops Last Field",
+			cfg:         Config{LogRequestBody: true, MaxBodySize: 20},
+			contentType: "application/x-www-form-urlencoded",
+			requestBody: "a=1&password=x&b=secret-value",
+			want: map[string]any{
+				"http.request.body":           "a=1&password=[REDACTED]",
+				"http.request.body.truncated": true,
+			},
+		},
+		{
+			name:         "Content Type Not Allowed",
+			cfg:          Config{LogRequestBody: true, LogResponseBody: true},
+			contentType:  "application/octet-stream",
+			requestBody:  "binary",
+			responseType: "image/png",
+			responseBody: "png",
+			notWant:      []string{"http.request.body", "http.response.body"},
+		},
+		{
+			name:         "Custom Hook",
+			cfg:          Config{LogResponseBody: true, RedactBody: func(_ string, b []byte) []byte { return bytes.ToUpper(b) }},
+			responseType: "text/plain",
+			responseBody: "hello",
+			want: map[string]any{
+				"http.response.body": "HELLO",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			cfg := tt.cfg
+			cfg.BaseHandler = slog.NewJSONHandler(buf, nil)
+			cfg.LogResponse = true
+			handler := RequestScoped(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				if tt.responseType != "" {
+					w.Header().Set("Content-Type", tt.responseType)
+				}
+				_, _ = io.WriteString(w, tt.responseBody)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.requestBody))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid JSON entry: %v", err)
+			}
+			for k, v := range tt.want {
+				if entry[k] != v {
+					t.Errorf("%s = %v, want %v", k, entry[k], v)
+				}
+			}
+			for _, k := range tt.notWant {
+				if _, ok := entry[k]; ok {
+					t.Errorf("unexpected attribute %s", k)
+				}
+			}
+		})
+	}
+}
+
+func TestBodySampleRate(t *testing.T) {
+	rate := func(f float64) *float64 { return &f }
+	tests := []struct {
+		name string
+		rate *float64
+		want int
+	}{
+		{"Unset Captures Every Request", nil, 20},
+		{"Zero Captures None", rate(0), 0},
+		{"One Captures Every Request", rate(1), 20},
+		{"Tiny Rate Captures None", rate(1e-12), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			handler := RequestScoped(Config{
+				BaseHandler:     slog.NewJSONHandler(buf, nil),
+				LogResponse:     true,
+				LogResponseBody: true,
+				BodySampleRate:  tt.rate,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				_, _ = io.WriteString(w, "hello")
+			}))
+			for range 20 {
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+
+			if got := strings.Count(buf.String(), `"http.response.body":"hello"`); got != tt.want {
+				t.Errorf("captured %d bodies, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchMediaType(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		mediaType string
+		want      bool
+	}{
+		{"application/json", "application/json", true},
+		{"text/*", "text/plain", true},
+		{"application/*+json", "application/problem+json", true},
+		{"application/*+json", "application/json", false},
+		{"text/*", "application/text", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchMediaType(tt.pattern, tt.mediaType); got != tt.want {
+			t.Errorf("matchMediaType(%q, %q) = %v, want %v", tt.pattern, tt.mediaType, got, tt.want)
+		}
+	}
+}
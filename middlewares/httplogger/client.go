@@ -0,0 +1,232 @@
+package httplogger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dusted-go/logging/v2/slogctx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ClientConfig holds configuration for the Transport round tripper.
+type ClientConfig struct {
+	// RequestIDHeader is the header which receives the request ID carried
+	// by the context of outbound requests. If empty, the X-Request-ID
+	// header is used.
+	RequestIDHeader string
+	// PropagateTrace determines whether to set the W3C traceparent header
+	// from the span carried by the context of outbound requests.
+	PropagateTrace bool
+	// TraceTimings determines whether to log the DNS, connect, TLS and
+	// first byte timings of each request, as measured with httptrace, in
+	// seconds as http.client.dns.duration, http.client.connect.duration,
+	// http.client.tls.duration and http.client.first_byte.duration.
+	TraceTimings bool
+	// ResponseLevel returns the level of the record for a status code.
+	// Failed requests are logged at ERROR. If nil, DefaultResponseLevel
+	// is used.
+	ResponseLevel func(status int) slog.Level
+	// RedactQueryParams is a list of query parameters whose values are
+	// redacted in url.full. If nil, DefaultRedactedQueryParams is used.
+	RedactQueryParams []string
+	// AllowQueryParams switches query parameter redaction to allowlist mode
+	// when not nil.
+	AllowQueryParams []string
+	// HashRedacted determines whether redacted values are replaced by a
+	// short HMAC-SHA256 of the value instead of Redacted.
+	HashRedacted bool
+	// RedactionKey is the key of the HMAC used by HashRedacted. If empty, a
+	// random key is generated per process.
+	RedactionKey []byte
+}
+
+// Transport returns an http.RoundTripper which logs each outbound request
+// with the logger carried by its context, following the OpenTelemetry HTTP
+// Client Semantic Conventions. It propagates the request ID and, if
+// configured, the trace context. Headers already set on a request are left
+// unchanged. If base is nil, http.DefaultTransport is used.
+func Transport(base http.RoundTripper, cfg ClientConfig) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := cfg.RequestIDHeader
+	if header == "" {
+		header = RequestIDHeader
+	}
+	level := DefaultResponseLevel
+	if cfg.ResponseLevel != nil {
+		level = cfg.ResponseLevel
+	}
+	redact := newRedactor(Config{
+		RedactQueryParams: cfg.RedactQueryParams,
+		AllowQueryParams:  cfg.AllowQueryParams,
+		HashRedacted:      cfg.HashRedacted,
+		RedactionKey:      cfg.RedactionKey,
+	})
+
+	return &transport{base: base, roundTrip: func(r *http.Request) (*http.Response, error) {
+		ctx := r.Context()
+		r = propagate(r, header, cfg.PropagateTrace)
+
+		var timings *clientTimings
+		if cfg.TraceTimings {
+			timings = &clientTimings{}
+			r = r.WithContext(httptrace.WithClientTrace(ctx, timings.trace()))
+		}
+
+		start := time.Now()
+		resp, err := base.RoundTrip(r)
+		duration := time.Since(start)
+
+		logger := slogctx.GetLogger(ctx)
+		attrs := clientAttributes(r, resp, err, duration, redact)
+		if timings != nil {
+			attrs = append(attrs, timings.attrs(start)...)
+		}
+		if err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "Failed HTTP request", attrs...)
+			return resp, err
+		}
+		logger.LogAttrs(ctx, level(resp.StatusCode), "Sent HTTP request", attrs...)
+		return resp, err
+	}}
+}
+
+// propagate returns a copy of r with the request ID and traceparent headers
+// set from its context, or r itself if there is nothing to set.
+func propagate(r *http.Request, header string, propagateTrace bool) *http.Request {
+	ctx := r.Context()
+	set := make(map[string]string, 2)
+	if requestID := slogctx.RequestID(ctx); requestID != "" && r.Header.Get(header) == "" {
+		set[header] = requestID
+	}
+	if span := trace.SpanContextFromContext(ctx); propagateTrace && span.IsValid() && r.Header.Get("Traceparent") == "" {
+		// More info: https://www.w3.org/TR/trace-context/#traceparent-header
+		set["Traceparent"] = fmt.Sprintf("00-%s-%s-%s", span.TraceID(), span.SpanID(), span.TraceFlags())
+	}
+	if len(set) == 0 {
+		return r
+	}
+	r = r.Clone(ctx)
+	for k, v := range set {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+// clientAttributes extracts attributes from an outbound request.
+// It follows OpenTelemetry HTTP Client Semantic Conventions.
+func clientAttributes(
+	r *http.Request,
+	resp *http.Response,
+	err error,
+	duration time.Duration,
+	redact redactor,
+) []slog.Attr {
+	// Log according to OpenTelemetry HTTP Client Semantic Conventions:
+	// https://opentelemetry.io/docs/specs/semconv/http/http-spans/#http-client
+	host, port := splitHostPort(r.URL.Host)
+	if port == -1 {
+		switch r.URL.Scheme {
+		case "https":
+			port = 443
+		case "http":
+			port = 80
+		}
+	}
+
+	u := *r.URL
+	u.RawQuery = redact.query(u.Query())
+	u.Fragment = ""
+
+	attrs := []slog.Attr{
+		slog.String("http.request.method", r.Method),
+		slog.String("server.address", host),
+		slog.Int("server.port", port),
+		slog.String("url.full", u.Redacted()),
+		slog.Float64("http.client.request.duration", duration.Seconds()),
+	}
+	if r.ContentLength > 0 {
+		attrs = append(attrs, slog.Int64("http.request.body.size", r.ContentLength))
+	}
+
+	if err != nil {
+		return append(attrs,
+			slog.String("error.type", fmt.Sprintf("%T", err)),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	attrs = append(attrs,
+		slog.Int("http.response.status_code", resp.StatusCode),
+		slog.String("network.protocol.version", strconv.Itoa(resp.ProtoMajor)+"."+strconv.Itoa(resp.ProtoMinor)),
+	)
+	if resp.ContentLength >= 0 {
+		attrs = append(attrs, slog.Int64("http.response.body.size", resp.ContentLength))
+	}
+	if resp.StatusCode >= 400 {
+		attrs = append(attrs, slog.String("error.type", strconv.Itoa(resp.StatusCode)))
+	}
+	return attrs
+}
+
+// clientTimings records the phases of an outbound request.
+type clientTimings struct {
+	mu                     sync.Mutex
+	dnsStart, dnsDone      time.Time
+	connectStart, connDone time.Time
+	tlsStart, tlsDone      time.Time
+	firstByte              time.Time
+	reused                 bool
+}
+
+func (t *clientTimings) trace() *httptrace.ClientTrace {
+	record := func(field *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { record(&t.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { record(&t.dnsDone) },
+		ConnectStart: func(string, string) { record(&t.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				record(&t.connDone)
+			}
+		},
+		TLSHandshakeStart: func() { record(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { record(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.reused = info.Reused
+		},
+		GotFirstResponseByte: func() { record(&t.firstByte) },
+	}
+}
+
+// attrs returns the durations of the recorded phases in seconds.
+func (t *clientTimings) attrs(start time.Time) []slog.Attr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	attrs := []slog.Attr{slog.Bool("http.client.connection.reused", t.reused)}
+	phase := func(key string, from, to time.Time) {
+		if !from.IsZero() && !to.IsZero() {
+			attrs = append(attrs, slog.Float64(key, to.Sub(from).Seconds()))
+		}
+	}
+	phase("http.client.dns.duration", t.dnsStart, t.dnsDone)
+	phase("http.client.connect.duration", t.connectStart, t.connDone)
+	phase("http.client.tls.duration", t.tlsStart, t.tlsDone)
+	phase("http.client.first_byte.duration", start, t.firstByte)
+	return attrs
+}
@@ -0,0 +1,89 @@
+package httplogger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/dusted-go/logging/v2/internal/groupattrs"
+)
+
+// requestHandler is a slog.Handler which adds request attributes to each
+// record at the top level. Attributes of the record take precedence over
+// them, and groups with the same key, such as httpRequest, are merged.
+//
+// The route is looked up when a record is handled because routing happens
+// after the request logger has been created.
+type requestHandler struct {
+	h      slog.Handler
+	attrs  []slog.Attr
+	req    **http.Request
+	route  bool
+	cfg    Config
+	nested groupattrs.Nested
+}
+
+func (h *requestHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *requestHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	if h.nested.Empty() {
+		h2.h = h.h.WithAttrs(attrs)
+	} else {
+		h2.nested = h.nested.WithAttrs(attrs)
+	}
+	return &h2
+}
+
+func (h *requestHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.nested = h.nested.WithGroup(name)
+	return &h2
+}
+
+func (h *requestHandler) Handle(ctx context.Context, r slog.Record) error {
+	top := h.attrs
+	if req := *h.req; h.route && req != nil {
+		top = append(top[:len(top):len(top)], routeAttrs(req, h.cfg)...)
+	}
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	if h.nested.Empty() {
+		top, attrs = mergeAttrs(top, attrs)
+	}
+	return h.h.Handle(ctx, h.nested.Record(r, top, attrs))
+}
+
+// mergeAttrs removes the attributes of top whose keys are also in attrs,
+// after merging the groups of top into the groups of attrs with the same key.
+func mergeAttrs(top, attrs []slog.Attr) ([]slog.Attr, []slog.Attr) {
+	index := make(map[string]int, len(attrs))
+	for i, a := range attrs {
+		index[a.Key] = i
+	}
+	var kept []slog.Attr
+	for _, t := range top {
+		i, ok := index[t.Key]
+		if !ok {
+			kept = append(kept, t)
+			continue
+		}
+		a := attrs[i]
+		if t.Value.Kind() != slog.KindGroup || a.Value.Kind() != slog.KindGroup {
+			continue
+		}
+		inner, group := mergeAttrs(t.Value.Group(), slices.Clone(a.Value.Group()))
+		attrs[i] = slog.Attr{Key: a.Key, Value: slog.GroupValue(append(inner, group...)...)}
+	}
+	return kept, attrs
+}
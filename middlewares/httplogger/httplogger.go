@@ -2,17 +2,25 @@ package httplogger
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dusted-go/logging/v2/slogctx"
-	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// RequestIDHeader is the default header which carries the request ID.
+// More info: https://http.dev/x-request-id
+const RequestIDHeader = "X-Request-ID"
+
 // Config holds configuration for the RequestScoped middleware.
 type Config struct {
 	// BaseHandler is the base slog.Handler to use for the request logger.
@@ -20,24 +28,183 @@ type Config struct {
 	BaseHandler slog.Handler
 	// AddTrace determines whether to add trace IDs to the logger.
 	AddTrace bool
+	// Schema selects the attribute keys of the records. If zero,
+	// SchemaOTel is used.
+	Schema Schema
+	// GCPProjectID is the Google Cloud project of the traces which are
+	// added by AddTrace with SchemaGCP.
+	GCPProjectID string
+	// AddRequestToLogger determines whether to add the method, URL,
+	// protocol, client address, user agent and referer of the request to
+	// every record of the request logger.
+	AddRequestToLogger bool
 	// LogRequest determines whether to log HTTP request metadata.
 	LogRequest bool
 	// ExcludeHeaders is a list of headers to exclude from logging.
 	ExcludeHeaders []string
+	// RedactHeaders is a list of headers whose values are redacted. If nil,
+	// DefaultRedactedHeaders is used; set an empty slice to log all headers
+	// verbatim. The names of cookies are still logged.
+	RedactHeaders []string
+	// AllowHeaders switches header redaction to allowlist mode when not nil:
+	// only the values of the listed headers are logged, every other header
+	// is redacted.
+	AllowHeaders []string
+	// RedactQueryParams is a list of query parameters whose values are
+	// redacted in url.query. If nil, DefaultRedactedQueryParams is used.
+	RedactQueryParams []string
+	// AllowQueryParams switches query parameter redaction to allowlist mode
+	// when not nil.
+	AllowQueryParams []string
+	// HashRedacted determines whether redacted values are replaced by a
+	// short HMAC-SHA256 of the value instead of Redacted, so that equal
+	// values can be correlated across records.
+	HashRedacted bool
+	// RedactionKey is the key of the HMAC used by HashRedacted. If empty, a
+	// random key is generated per process, so that hashes only correlate
+	// within one process.
+	RedactionKey []byte
+	// EchoRequestID determines whether to set the request ID header of the
+	// response to the request ID.
+	EchoRequestID bool
+	// RequestIDHeader is the header which carries the request ID, such as
+	// "X-Correlation-ID". If empty, the X-Request-ID header is used.
+	RequestIDHeader string
+	// GenerateRequestID generates the ID of a request without a valid
+	// request ID header, such as UUIDv7, ULID or KSUID. If nil, UUIDv4 is
+	// used.
+	GenerateRequestID func() string
+	// ValidateRequestID reports whether an incoming request ID is
+	// acceptable. Rejected IDs are replaced by a generated one and logged at
+	// WARN as request.id.rejected. If nil, ValidRequestID is used.
+	ValidateRequestID func(id string) bool
+	// RequestLevel optionally returns a minimum level for a single request,
+	// such as DEBUG for flagged traffic. The level is stored with
+	// slogctx.WithLevel and requires the BaseHandler to be wrapped with
+	// slogctx.NewHandler.
+	RequestLevel func(r *http.Request) (slog.Level, bool)
+	// LogResponse determines whether to log the response status, the number
+	// of bytes read and written, and the duration in seconds as
+	// http.server.request.duration once the request completes.
+	LogResponse bool
+	// LogRequestBody determines whether to add the start of the request
+	// body to the completion record as http.request.body. The body is
+	// copied while the handler reads it. Requires LogResponse.
+	LogRequestBody bool
+	// LogResponseBody determines whether to add the start of the response
+	// body to the completion record as http.response.body. Requires
+	// LogResponse.
+	LogResponseBody bool
+	// MaxBodySize is the maximum number of bytes captured per body. If zero,
+	// DefaultMaxBodySize is used.
+	MaxBodySize int
+	// BodyContentTypes lists the media types of the bodies which are
+	// captured. A pattern such as "text/*" or "application/*+json" matches
+	// any subtype. If nil, DefaultBodyContentTypes is used.
+	BodyContentTypes []string
+	// BodySampleRate is the fraction of requests, between 0 and 1, whose
+	// bodies are captured. Like Rule.SampleRate, 0 captures none and 1
+	// captures all. If nil, the bodies of every request are captured.
+	BodySampleRate *float64
+	// RedactBodyFields lists the JSON object keys and form fields whose
+	// values are redacted in captured bodies. If nil,
+	// DefaultRedactedBodyFields is used.
+	RedactBodyFields []string
+	// RedactBody optionally rewrites a captured body after its fields have
+	// been redacted.
+	RedactBody func(contentType string, body []byte) []byte
+	// RouteExtractor returns the route template of a request, such as
+	// "/users/{id}", once the handler has served it. It is needed for
+	// routers other than http.ServeMux. If nil, ServeMuxRoute is used.
+	RouteExtractor func(r *http.Request) string
+	// AddRouteToLogger determines whether the request logger adds
+	// http.route to the records which are logged after routing.
+	AddRouteToLogger bool
+	// SkipRules skip or sample the request and completion records of
+	// matching requests, such as health checks. The first matching rule
+	// applies. Skipped requests still get the request-scoped logger, and
+	// their completion record is still logged at ERROR and above or when
+	// the request is slower than SlowRequestThreshold.
+	SkipRules []Rule
+	// SlowRequestThreshold is the duration above which the completion
+	// record of a skipped request is logged. Zero disables it.
+	SlowRequestThreshold time.Duration
+	// AccessLog optionally receives a line in an Apache access log format
+	// for each completed request, using the same client resolution,
+	// redaction and skip rules as the structured records.
+	AccessLog io.Writer
+	// AccessLogFormat is the format of the AccessLog lines, such as
+	// CommonLogFormat, CombinedLogFormat or a custom format like
+	// `%h %l %u %t "%r" %>s %b %D`. If empty, CommonLogFormat is used.
+	AccessLogFormat string
+	// ResponseLevel returns the level of the completion record for a status
+	// code. If nil, DefaultResponseLevel is used.
+	ResponseLevel func(status int) slog.Level
+	// RecoverPanics determines whether to recover panics in the handler and
+	// log them at ERROR with the request-scoped logger. Panics with
+	// http.ErrAbortHandler are not recovered.
+	RecoverPanics bool
+	// PanicHandler writes the response after a recovered panic, unless the
+	// headers have already been sent. If nil, a plain text
+	// 500 Internal Server Error response is written.
+	PanicHandler http.Handler
+	// LogTLS determines whether to add the protocol, cipher, server name,
+	// resumption and ALPN protocol of TLS connections to the request record
+	// as tls.* attributes, along with the subject, issuer, serial number,
+	// alternative names and validity of mTLS client certificates.
+	LogTLS bool
+	// TrustedProxies lists the networks of proxies whose Forwarded,
+	// X-Forwarded-* and X-Real-IP headers are trusted. The client address is
+	// the first address from the right of the forwarded chain which is not
+	// within these networks.
+	TrustedProxies []netip.Prefix
+	// TrustedHops is the number of proxies in front of the server whose
+	// headers are trusted, including the immediate peer. It takes precedence
+	// over TrustedProxies. If neither is set, forwarded headers are ignored
+	// and the client address is taken from the connection.
+	TrustedHops int
+	// LogProxyChain determines whether to log the addresses of the client
+	// and each proxy as http.request.forwarded_chain.
+	LogProxyChain bool
+}
+
+// DefaultResponseLevel logs server errors (5xx) at ERROR, client errors (4xx)
+// at WARN and every other response at INFO.
+func DefaultResponseLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
 }
 
 // RequestScoped creates a middleware that adds a request-scoped logger to the context.
 func RequestScoped(cfg Config) func(http.Handler) http.Handler {
+	var accessLog *accessLogger
+	if cfg.AccessLog != nil {
+		accessLog = newAccessLogger(cfg.AccessLog, cfg.AccessLogFormat)
+	}
+	redact := newRedactor(cfg)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(
 			func(w http.ResponseWriter, r *http.Request) {
 				ctx := r.Context()
+				req := &requestLog{r: r, cfg: cfg, redact: redact}
 
 				// Always parse an existing X-Request-ID header or generate a new one.
 				// More info: https://http.dev/x-request-id
-				requestID := r.Header.Get("X-Request-ID")
-				if requestID == "" {
-					requestID = uuid.NewString()
+				requestID, rejectedID := requestIDOf(r, cfg)
+				ctx = slogctx.WithRequestID(ctx, requestID)
+				if cfg.RequestLevel != nil {
+					if level, ok := cfg.RequestLevel(r); ok {
+						ctx = slogctx.WithLevel(ctx, level)
+					}
+				}
+				if cfg.EchoRequestID {
+					w.Header().Set(requestIDHeader(cfg), requestID)
 				}
 
 				var handler slog.Handler
@@ -49,68 +216,157 @@ func RequestScoped(cfg Config) func(http.Handler) http.Handler {
 
 				// Create a request-scoped handler with request ID.
 				reqHandler := handler.WithAttrs(
-					[]slog.Attr{slog.String("request.id", requestID)})
+					[]slog.Attr{cfg.Schema.requestIDAttr(requestID)})
 
 				// Add trace IDs if requested and available.
-				if cfg.AddTrace {
-					span := trace.SpanFromContext(ctx).SpanContext()
-					if span.IsValid() {
-						reqHandler = reqHandler.WithAttrs([]slog.Attr{
-							slog.String("trace_id", span.TraceID().String()),
-							slog.String("span_id", span.SpanID().String()),
-						})
+				span := trace.SpanFromContext(ctx).SpanContext()
+				if span.IsValid() {
+					ctx = slogctx.WithTraceID(ctx, span.TraceID().String())
+					if cfg.AddTrace {
+						reqHandler = reqHandler.WithAttrs(cfg.Schema.traceAttrs(span, cfg.GCPProjectID))
 					}
 				}
 
+				// Add the request attributes, and the route once it is known by
+				// the router.
+				var routed *http.Request
+				if cfg.AddRequestToLogger || cfg.AddRouteToLogger {
+					rh := &requestHandler{h: reqHandler, req: &routed, route: cfg.AddRouteToLogger, cfg: cfg}
+					if cfg.AddRequestToLogger {
+						rh.attrs = loggerRequestAttributes(req.attributes(), cfg)
+					}
+					reqHandler = rh
+				}
+
 				// Create a request-scoped logger and add it to the request context.
 				logger := slog.New(reqHandler)
 				ctx = slogctx.WithLogger(ctx, logger)
 				r = r.WithContext(ctx)
+				routed = r
+
+				if rejectedID != "" {
+					logger.WarnContext(ctx, "Replaced invalid request ID",
+						slog.String("request.id.rejected", rejectedID))
+				}
 
 				// Optionally log HTTP request metadata.
-				if cfg.LogRequest {
-					attrs := requestAttributes(r, cfg.ExcludeHeaders)
-					logger.Info("Processing HTTP request", attrs...)
+				logged := shouldLog(r, cfg.SkipRules)
+				if cfg.LogRequest && logged {
+					attrs := cfg.Schema.convert(append(req.attributes(), routeAttrs(r, cfg)...))
+					logger.LogAttrs(ctx, slog.LevelInfo, "Processing HTTP request", attrs...)
+				}
+
+				if !cfg.LogResponse && !cfg.RecoverPanics && accessLog == nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				start := time.Now()
+				rw := newResponseWriter(w)
+				var body *bodyCounter
+				if cfg.LogResponse && r.Body != nil && r.Body != http.NoBody {
+					body = &bodyCounter{ReadCloser: r.Body}
+					r.Body = body
+				}
+				if cfg.LogResponse && logged {
+					captureBodies(r, rw, body, cfg)
 				}
 
-				next.ServeHTTP(w, r)
+				if cfg.RecoverPanics {
+					serveRecovered(next, rw, r, req, logger, cfg)
+				} else {
+					next.ServeHTTP(rw.wrap(), r)
+				}
+
+				if !cfg.LogResponse && accessLog == nil {
+					return
+				}
+				level := DefaultResponseLevel
+				if cfg.ResponseLevel != nil {
+					level = cfg.ResponseLevel
+				}
+				duration := time.Since(start)
+				recordLevel := level(rw.Status())
+				if !logged && recordLevel < slog.LevelError &&
+					(cfg.SlowRequestThreshold <= 0 || duration < cfg.SlowRequestThreshold) {
+					return
+				}
+				if accessLog != nil {
+					accessLog.log(r, rw, req, start, duration)
+				}
+				if !cfg.LogResponse {
+					return
+				}
+				attrs := responseAttributes(r, rw, body, duration)
+				attrs = append(attrs, routeAttrs(r, cfg)...)
+				attrs = append(attrs, bodyAttributes(r, rw, body, cfg)...)
+				attrs = cfg.Schema.convert(attrs)
+				logger.LogAttrs(ctx, recordLevel, "Completed HTTP request", attrs...)
 			},
 		)
 	}
 }
 
-// requestAttributes extracts attributes from the HTTP request.
+// requestLog computes the client and the attributes of a request at most
+// once, and shares them between the records and the access log line of the
+// request.
+type requestLog struct {
+	r      *http.Request
+	cfg    Config
+	redact redactor
+	client *clientInfo
+	attrs  []slog.Attr
+}
+
+// clientInfo returns the client of the request.
+func (l *requestLog) clientInfo() clientInfo {
+	if l.client == nil {
+		client := resolveClient(l.r, l.cfg)
+		l.client = &client
+	}
+	return *l.client
+}
+
+// attributes returns the attributes of the request, except for http.route
+// which is only known once the router has run. The result must not be
+// modified.
+func (l *requestLog) attributes() []slog.Attr {
+	if l.attrs == nil {
+		l.attrs = slices.Clip(requestAttributes(l.r, l.cfg, l.redact, l.clientInfo()))
+	}
+	return l.attrs
+}
+
+// requestAttributes extracts attributes from the HTTP request of client.
 // It follows OpenTelemetry HTTP Server Semantic Conventions.
-func requestAttributes(r *http.Request, excludeHeaders []string) []any {
+func requestAttributes(r *http.Request, cfg Config, redact redactor, client clientInfo) []slog.Attr {
 	// Log according to OpenTelemetry HTTP Server Semantic Conventions:
 	// https://opentelemetry.io/docs/specs/semconv/http/http-spans/#http-server
 
-	// Handle Forwarded header (RFC 7239)
-	forwarded := ParseForwarded(r.Header.Get("Forwarded"))
-
 	// Determine Host and Port
 	// Priority: Forwarded > X-Forwarded-Host > Host header
 	host := ""
 	port := -1
 
-	if len(forwarded) > 0 && forwarded[0].Host != "" {
-		host, port = splitHostPort(forwarded[0].Host)
+	if client.forwarded != nil && client.forwarded.Host != "" {
+		host, port = splitHostPort(client.forwarded.Host)
+	}
+
+	if host == "" && client.trusted {
+		host, port = splitHostPort(r.Header.Get("X-Forwarded-Host"))
 	}
 
 	if host == "" {
-		host, port = firstHostPort(
-			r.Header.Get("X-Forwarded-Host"),
-			r.Host,
-		)
+		host, port = splitHostPort(r.Host)
 	}
 
 	// Determine Scheme
 	// Priority: Forwarded > X-Forwarded-Proto > TLS > Default (http)
 	scheme := ""
-	if len(forwarded) > 0 && forwarded[0].Proto != "" {
-		scheme = forwarded[0].Proto
+	if client.forwarded != nil && client.forwarded.Proto != "" {
+		scheme = client.forwarded.Proto
 	}
-	if scheme == "" {
+	if scheme == "" && client.trusted {
 		scheme = r.Header.Get("X-Forwarded-Proto")
 	}
 	if scheme == "" {
@@ -131,23 +387,6 @@ func requestAttributes(r *http.Request, excludeHeaders []string) []any {
 		}
 	}
 
-	// Determine Client Address
-	// Priority: Forwarded > X-Real-IP > X-Forwarded-For > RemoteAddr
-	clientAddress := ""
-	clientPort := -1
-
-	if len(forwarded) > 0 && forwarded[0].For != "" {
-		clientAddress, clientPort = splitHostPort(forwarded[0].For)
-	}
-
-	if clientAddress == "" {
-		clientAddress, clientPort = firstHostPort(
-			r.Header.Get("X-Real-IP"),
-			parseXForwardedFor(r.Header.Get("X-Forwarded-For")),
-			r.RemoteAddr,
-		)
-	}
-
 	networkProtocol := "http"
 	networkProtocolVersion := fmt.Sprintf("%d.%d", r.ProtoMajor, r.ProtoMinor)
 	protoName, protoVersion, ok := strings.Cut(r.Proto, "/")
@@ -155,10 +394,18 @@ func requestAttributes(r *http.Request, excludeHeaders []string) []any {
 		networkProtocol = strings.ToLower(protoName)
 		networkProtocolVersion = protoVersion
 	}
+	if cfg.LogTLS && r.TLS != nil && r.TLS.NegotiatedProtocol != "" {
+		// Prefer the protocol negotiated with ALPN.
+		name, version := alpnProtocol(r.TLS.NegotiatedProtocol)
+		networkProtocol = name
+		if version != "" {
+			networkProtocolVersion = version
+		}
+	}
 
-	urlQuery := r.URL.Query().Encode()
+	urlQuery := redact.query(r.URL.Query())
 
-	requestAttrs := []any{
+	requestAttrs := []slog.Attr{
 		slog.String("server.address", host),
 		slog.Int("server.port", port),
 		slog.String("network.protocol.name", networkProtocol),
@@ -168,16 +415,27 @@ func requestAttributes(r *http.Request, excludeHeaders []string) []any {
 		slog.String("url.path", r.URL.Path),
 		slog.String("url.scheme", scheme),
 		slog.String("user_agent.original", r.UserAgent()),
-		slog.String("client.address", clientAddress),
+		slog.String("client.address", client.address),
 	}
 
-	if clientPort != -1 {
+	if client.port != -1 {
 		requestAttrs = append(
 			requestAttrs,
-			slog.Int("client.port", clientPort),
+			slog.Int("client.port", client.port),
 		)
 	}
 
+	if cfg.LogProxyChain && len(client.chain) > 0 {
+		requestAttrs = append(
+			requestAttrs,
+			slog.Any("http.request.forwarded_chain", client.chain),
+		)
+	}
+
+	if cfg.LogTLS && r.TLS != nil {
+		requestAttrs = append(requestAttrs, tlsAttributes(r.TLS)...)
+	}
+
 	if urlQuery != "" {
 		requestAttrs = append(
 			requestAttrs,
@@ -201,9 +459,10 @@ func requestAttributes(r *http.Request, excludeHeaders []string) []any {
 	}
 
 	// Iterate over all request headers and add them as attributes,
-	// excluding any headers in the excludeHeaders list.
-	excludeMap := make(map[string]struct{}, len(excludeHeaders))
-	for _, h := range excludeHeaders {
+	// excluding any headers in the excludeHeaders list and redacting
+	// sensitive values.
+	excludeMap := make(map[string]struct{}, len(cfg.ExcludeHeaders))
+	for _, h := range cfg.ExcludeHeaders {
 		excludeMap[strings.ToLower(h)] = struct{}{}
 	}
 
@@ -212,18 +471,108 @@ func requestAttributes(r *http.Request, excludeHeaders []string) []any {
 		if _, excluded := excludeMap[lowerName]; excluded {
 			continue
 		}
-		// Join multiple header values with a comma, as per RFC 7230.
-		joinedValues := strings.Join(values, ",")
 		attrKey := fmt.Sprintf("http.request.header.%s", lowerName)
 		requestAttrs = append(
 			requestAttrs,
-			slog.String(attrKey, joinedValues),
+			slog.String(attrKey, redact.header(lowerName, values)),
 		)
 	}
 
 	return requestAttrs
 }
 
+// loggerAttributeKeys are the request attributes added by
+// Config.AddRequestToLogger.
+var loggerAttributeKeys = map[string]struct{}{
+	"http.request.method":         {},
+	"server.address":              {},
+	"server.port":                 {},
+	"url.scheme":                  {},
+	"url.path":                    {},
+	"url.query":                   {},
+	"network.protocol.name":       {},
+	"network.protocol.version":    {},
+	"client.address":              {},
+	"user_agent.original":         {},
+	"http.request.header.referer": {},
+}
+
+// loggerRequestAttributes returns the request attributes of requestAttrs
+// which are added to the request logger.
+func loggerRequestAttributes(requestAttrs []slog.Attr, cfg Config) []slog.Attr {
+	var attrs []slog.Attr
+	for _, a := range requestAttrs {
+		if _, ok := loggerAttributeKeys[a.Key]; ok {
+			attrs = append(attrs, a)
+		}
+	}
+	return cfg.Schema.convert(attrs)
+}
+
+// serveRecovered calls next and recovers from a panic, unless it is
+// http.ErrAbortHandler which net/http expects to be propagated.
+func serveRecovered(
+	next http.Handler,
+	rw *responseWriter,
+	r *http.Request,
+	req *requestLog,
+	logger *slog.Logger,
+	cfg Config,
+) {
+	w := rw.wrap()
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		if v == http.ErrAbortHandler {
+			panic(v)
+		}
+
+		attrs := cfg.Schema.convert(append(req.attributes(), routeAttrs(r, cfg)...))
+		attrs = append(attrs,
+			slog.Any("panic", v),
+			slog.String("stack", string(debug.Stack())),
+		)
+		logger.LogAttrs(r.Context(), slog.LevelError, "Recovered from panic in HTTP handler", attrs...)
+
+		if rw.wroteHeader || rw.hijacked {
+			return
+		}
+		if cfg.PanicHandler != nil {
+			cfg.PanicHandler.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}()
+	next.ServeHTTP(w, r)
+}
+
+// responseAttributes extracts attributes from the completed HTTP request.
+// It follows OpenTelemetry HTTP Server Semantic Conventions.
+func responseAttributes(
+	r *http.Request,
+	rw *responseWriter,
+	body *bodyCounter,
+	duration time.Duration,
+) []slog.Attr {
+	var bodySize int64
+	if body != nil {
+		bodySize = body.bytes
+	}
+	attrs := []slog.Attr{
+		slog.String("http.request.method", r.Method),
+		slog.String("url.path", r.URL.Path),
+		slog.Int64("http.request.body.size", bodySize),
+		slog.Int64("http.response.body.size", rw.bytes),
+		slog.Float64("http.server.request.duration", duration.Seconds()),
+	}
+	if status := rw.Status(); status != 0 {
+		attrs = append(attrs, slog.Int("http.response.status_code", status))
+	}
+	return attrs
+}
+
 // splitHostPort splits a network address hostport of the form "host",
 // "host%zone", "[host]", "[host%zone], "host:port", "host%zone:port",
 // "[host]:port", "[host%zone]:port", or ":port" into host or host%zone and
@@ -267,22 +616,3 @@ func splitHostPort(hostport string) (host string, port int) {
 	}
 	return host, int(p) // nolint: gosec  // Bit size of 16 checked above.
 }
-
-// Return the request host and port from the first non-empty source.
-func firstHostPort(source ...string) (host string, port int) {
-	for _, hostport := range source {
-		host, port = splitHostPort(hostport)
-		if host != "" || port > 0 {
-			break
-		}
-	}
-	return host, port
-}
-
-// parseXForwardedFor extracts the first IP address from a X-Forwarded-For header value.
-func parseXForwardedFor(xForwardedFor string) string {
-	if idx := strings.Index(xForwardedFor, ","); idx >= 0 {
-		xForwardedFor = xForwardedFor[:idx]
-	}
-	return strings.TrimSpace(xForwardedFor)
-}
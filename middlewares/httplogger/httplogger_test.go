@@ -1,22 +1,29 @@
 package httplogger
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/json"
+	"io"
 	"log/slog"
+	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"reflect"
-	"strings"
 	"testing"
+
+	"github.com/dusted-go/logging/v2/slogctx"
 )
 
 func TestRequestAttributes(t *testing.T) {
 	tests := []struct {
-		name           string
-		headers        map[string]string
-		remoteAddr     string
-		tls            bool
-		excludeHeaders []string
-		want           map[string]any
+		name       string
+		headers    map[string]string
+		remoteAddr string
+		tls        bool
+		cfg        Config
+		want       map[string]any
+		notWant    []string
 	}{
 		{
 			name:       "Basic Request",
@@ -54,6 +61,10 @@ func TestRequestAttributes(t *testing.T) {
 				"X-Forwarded-Proto": "https",
 			},
 			remoteAddr: "127.0.0.1:12345",
+			cfg: Config{TrustedProxies: []netip.Prefix{
+				netip.MustParsePrefix("127.0.0.0/8"),
+				netip.MustParsePrefix("10.0.0.2/32"),
+			}},
 			want: map[string]any{
 				"client.address": "10.0.0.1",
 				"server.address": "example.com",
@@ -67,6 +78,7 @@ func TestRequestAttributes(t *testing.T) {
 				"Forwarded": "for=192.0.2.60;proto=http;by=203.0.113.43, for=198.51.100.17",
 			},
 			remoteAddr: "127.0.0.1:12345",
+			cfg:        Config{TrustedHops: 2},
 			want: map[string]any{
 				"client.address": "192.0.2.60",
 				"url.scheme":     "http",
@@ -79,6 +91,7 @@ func TestRequestAttributes(t *testing.T) {
 				"Forwarded": "host=api.example.com:8443;proto=https",
 			},
 			remoteAddr: "127.0.0.1:12345",
+			cfg:        Config{TrustedHops: 1},
 			want: map[string]any{
 				"server.address": "api.example.com",
 				"server.port":    int64(8443),
@@ -92,11 +105,70 @@ func TestRequestAttributes(t *testing.T) {
 				"X-API-Key":     "12345",
 				"User-Agent":    "Go-Test",
 			},
-			excludeHeaders: []string{"Authorization", "x-api-key"},
+			cfg: Config{ExcludeHeaders: []string{"Authorization", "x-api-key"}},
 			want: map[string]any{
 				"http.request.header.user-agent": "Go-Test",
 			},
-			// We need to check that excluded headers are NOT present
+			notWant: []string{"http.request.header.authorization", "http.request.header.x-api-key"},
+		},
+		{
+			name: "Untrusted Peer",
+			headers: map[string]string{
+				"X-Forwarded-For":   "203.0.113.7",
+				"X-Forwarded-Host":  "spoofed.example.com",
+				"X-Forwarded-Proto": "https",
+				"X-Real-IP":         "203.0.113.8",
+			},
+			remoteAddr: "198.51.100.1:4000",
+			cfg: Config{
+				TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+				LogProxyChain:  true,
+			},
+			want: map[string]any{
+				"client.address": "198.51.100.1",
+				"client.port":    int64(4000),
+				"server.address": "example.com",
+				"url.scheme":     "http",
+			},
+			notWant: []string{"http.request.forwarded_chain"},
+		},
+		{
+			name: "Spoofed X-Forwarded-For Behind Trusted Proxy",
+			headers: map[string]string{
+				"X-Forwarded-For": "1.2.3.4, 203.0.113.7, 10.0.0.5",
+			},
+			remoteAddr: "10.0.0.1:4000",
+			cfg: Config{
+				TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+				LogProxyChain:  true,
+			},
+			want: map[string]any{
+				"client.address":               "203.0.113.7",
+				"http.request.forwarded_chain": []string{"1.2.3.4", "203.0.113.7", "10.0.0.5", "10.0.0.1"},
+			},
+			notWant: []string{"client.port"},
+		},
+		{
+			name: "X-Real-IP",
+			headers: map[string]string{
+				"X-Real-IP": "203.0.113.9",
+			},
+			remoteAddr: "10.0.0.1:4000",
+			cfg:        Config{TrustedHops: 1},
+			want: map[string]any{
+				"client.address": "203.0.113.9",
+			},
+		},
+		{
+			name: "Trusted Hops Exceed Chain",
+			headers: map[string]string{
+				"X-Forwarded-For": "203.0.113.7",
+			},
+			remoteAddr: "10.0.0.1:4000",
+			cfg:        Config{TrustedHops: 3},
+			want: map[string]any{
+				"client.address": "203.0.113.7",
+			},
 		},
 	}
 
@@ -118,12 +190,10 @@ func TestRequestAttributes(t *testing.T) {
 				req.TLS = &tls.ConnectionState{} // Use correct type
 			}
 
-			attrs := requestAttributes(req, tt.excludeHeaders)
+			attrs := requestAttributes(req, tt.cfg, newRedactor(tt.cfg), resolveClient(req, tt.cfg))
 			attrMap := make(map[string]any)
 			for _, a := range attrs {
-				if kv, ok := a.(slog.Attr); ok {
-					attrMap[kv.Key] = kv.Value.Any()
-				}
+				attrMap[a.Key] = a.Value.Any()
 			}
 
 			for k, v := range tt.want {
@@ -132,12 +202,9 @@ func TestRequestAttributes(t *testing.T) {
 				}
 			}
 
-			// Check excluded headers
-			for _, h := range tt.excludeHeaders {
-				key := "http.request.header." + strings.ToLower(h)
-				_, exists := attrMap[key]
-				if exists {
-					t.Errorf("Header %s should be excluded", h)
+			for _, k := range tt.notWant {
+				if _, exists := attrMap[k]; exists {
+					t.Errorf("Attribute %s should not be present", k)
 				}
 			}
 		})
@@ -170,3 +237,90 @@ func TestSplitHostPort(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestIDIsPropagated(t *testing.T) {
+	var outbound string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Get(RequestIDHeader)
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: PropagateRequestID(upstream.Client().Transport)}
+	var requestID string
+	mw := RequestScoped(Config{
+		BaseHandler:   slog.NewJSONHandler(io.Discard, nil),
+		EchoRequestID: true,
+	})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = slogctx.RequestID(r.Context())
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+		resp.Body.Close()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if requestID != "abc-123" {
+		t.Errorf("slogctx.RequestID = %q, want %q", requestID, "abc-123")
+	}
+	if got := rec.Header().Get(RequestIDHeader); got != "abc-123" {
+		t.Errorf("response header = %q, want %q", got, "abc-123")
+	}
+	if outbound != "abc-123" {
+		t.Errorf("outbound header = %q, want %q", outbound, "abc-123")
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	buf := &bytes.Buffer{}
+	mw := RequestScoped(Config{
+		BaseHandler:   slog.NewJSONHandler(buf, nil),
+		RecoverPanics: true,
+	})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON entry: %v", err)
+	}
+	if entry["level"] != "ERROR" || entry["panic"] != "boom" || entry["request.id"] != "abc-123" || entry["url.path"] != "/orders" {
+		t.Errorf("unexpected panic entry: %v", entry)
+	}
+	if stack, _ := entry["stack"].(string); stack == "" {
+		t.Errorf("expected stack in panic entry")
+	}
+}
+
+func TestRecoverPanicsPropagatesErrAbortHandler(t *testing.T) {
+	mw := RequestScoped(Config{
+		BaseHandler:   slog.NewJSONHandler(io.Discard, nil),
+		RecoverPanics: true,
+	})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler to propagate, got: %v", v)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,112 @@
+package httplogger
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientInfo is the client of a request as resolved from the trusted
+// proxy headers.
+type clientInfo struct {
+	address string
+	port    int
+	// trusted reports whether the immediate peer is a trusted proxy, in which
+	// case its Forwarded and X-Forwarded-* headers are used.
+	trusted bool
+	// forwarded is the Forwarded element added by the outermost trusted proxy.
+	forwarded *ForwardedElement
+	// chain holds the addresses of the client and each proxy, ending with the
+	// immediate peer.
+	chain []string
+}
+
+// resolveClient determines the client of r. The Forwarded, X-Forwarded-For
+// and X-Real-IP headers are only used when the immediate peer is a trusted
+// proxy. The chain of addresses is then walked from the right, skipping
+// trusted proxies, to find the client.
+func resolveClient(r *http.Request, cfg Config) clientInfo {
+	peer, peerPort := splitHostPort(r.RemoteAddr)
+	info := clientInfo{address: peer, port: peerPort}
+	if cfg.TrustedHops <= 0 && !isTrustedProxy(peer, cfg.TrustedProxies) {
+		return info
+	}
+	info.trusted = true
+
+	forwarded := ParseForwarded(r.Header.Get("Forwarded"))
+	var hops []string
+	if len(forwarded) > 0 {
+		for _, elem := range forwarded {
+			hops = append(hops, elem.For)
+		}
+	} else {
+		hops = parseXForwardedFor(r.Header.Get("X-Forwarded-For"))
+	}
+
+	if len(hops) == 0 {
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			info.address, info.port = splitHostPort(realIP)
+			info.chain = []string{info.address, peer}
+		}
+		return info
+	}
+
+	chain := make([]string, 0, len(hops)+1)
+	for _, hop := range hops {
+		host, _ := splitHostPort(hop)
+		chain = append(chain, host)
+	}
+	chain = append(chain, peer)
+	info.chain = chain
+
+	var i int
+	if cfg.TrustedHops > 0 {
+		i = max(len(chain)-1-cfg.TrustedHops, 0)
+	} else {
+		i = len(chain) - 1
+		for i > 0 && isTrustedProxy(chain[i], cfg.TrustedProxies) {
+			i--
+		}
+	}
+
+	if len(forwarded) > 0 {
+		info.forwarded = &forwarded[i]
+	}
+	if address, port := splitHostPort(hops[i]); address != "" {
+		info.address, info.port = address, port
+	}
+	return info
+}
+
+// isTrustedProxy reports whether host is within one of the trusted prefixes.
+func isTrustedProxy(host string, proxies []netip.Prefix) bool {
+	if len(proxies) == 0 {
+		return false
+	}
+	// Strip an IPv6 zone, which netip.Prefix never contains.
+	if i := strings.IndexByte(host, '%'); i >= 0 {
+		host = host[:i]
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseXForwardedFor splits a X-Forwarded-For header value into addresses.
+func parseXForwardedFor(xForwardedFor string) []string {
+	var addresses []string
+	for _, address := range strings.Split(xForwardedFor, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
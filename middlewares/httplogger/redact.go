@@ -0,0 +1,228 @@
+package httplogger
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the values of redacted headers, query parameters and
+// cookies unless Config.HashRedacted is set.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedHeaders are the headers redacted when
+// Config.RedactHeaders is nil.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// DefaultRedactedQueryParams are the query parameters redacted when
+// Config.RedactQueryParams is nil.
+var DefaultRedactedQueryParams = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"client_secret",
+	"id_token",
+	"password",
+	"refresh_token",
+	"secret",
+	"signature",
+	"token",
+}
+
+// redactor decides which header and query parameter values are redacted.
+type redactor struct {
+	headers     map[string]struct{}
+	allowHeader map[string]struct{}
+	params      map[string]struct{}
+	allowParam  map[string]struct{}
+	// key is the HMAC key of redacted values, or nil if they are not hashed.
+	key []byte
+}
+
+func newRedactor(cfg Config) redactor {
+	headers := cfg.RedactHeaders
+	if headers == nil {
+		headers = DefaultRedactedHeaders
+	}
+	params := cfg.RedactQueryParams
+	if params == nil {
+		params = DefaultRedactedQueryParams
+	}
+	return redactor{
+		headers:     lowerSet(headers),
+		allowHeader: lowerSet(cfg.AllowHeaders),
+		params:      lowerSet(params),
+		allowParam:  lowerSet(cfg.AllowQueryParams),
+		key:         hashKey(cfg),
+	}
+}
+
+// processKey is the HMAC key of redacted values when Config.RedactionKey is
+// not set. It is random, so hashes only correlate within one process.
+var processKey = sync.OnceValue(func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+})
+
+// hashKey returns the HMAC key of redacted values, or nil if they are not
+// hashed.
+func hashKey(cfg Config) []byte {
+	if !cfg.HashRedacted {
+		return nil
+	}
+	if len(cfg.RedactionKey) > 0 {
+		return cfg.RedactionKey
+	}
+	return processKey()
+}
+
+// header returns the value to log for the header name, which must be
+// lower case.
+func (rd redactor) header(name string, values []string) string {
+	if !rd.redacts(name, rd.headers, rd.allowHeader) {
+		// Join multiple header values with a comma, as per RFC 7230.
+		return strings.Join(values, ",")
+	}
+	switch name {
+	case "cookie":
+		return rd.cookies(values)
+	case "set-cookie":
+		return rd.setCookies(values)
+	}
+	return rd.value(strings.Join(values, ","))
+}
+
+// query returns the encoded query with the values of redacted parameters
+// replaced. Like url.Values.Encode, parameters are sorted by key.
+func (rd redactor) query(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		redact := rd.redacts(strings.ToLower(k), rd.params, rd.allowParam)
+		keyEscaped := url.QueryEscape(k)
+		for _, v := range values[k] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(keyEscaped)
+			b.WriteByte('=')
+			if redact {
+				b.WriteString(rd.value(v))
+			} else {
+				b.WriteString(url.QueryEscape(v))
+			}
+		}
+	}
+	return b.String()
+}
+
+// rawQuery returns the raw query with the values of redacted parameters
+// replaced, keeping the order and encoding of the other parameters.
+func (rd redactor) rawQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	pairs := strings.Split(raw, "&")
+	for i, pair := range pairs {
+		k, v, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			key = k
+		}
+		if !rd.redacts(strings.ToLower(key), rd.params, rd.allowParam) {
+			continue
+		}
+		if value, err := url.QueryUnescape(v); err == nil {
+			v = value
+		}
+		pairs[i] = k + "=" + rd.value(v)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// cookies logs the names of the cookies of a Cookie header with redacted
+// values.
+func (rd redactor) cookies(values []string) string {
+	var parts []string
+	for _, line := range values {
+		cookies, err := http.ParseCookie(line)
+		if err != nil {
+			return rd.value(strings.Join(values, ","))
+		}
+		for _, c := range cookies {
+			parts = append(parts, c.Name+"="+rd.value(c.Value))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// setCookies logs the names of the cookies of Set-Cookie headers with
+// redacted values.
+func (rd redactor) setCookies(values []string) string {
+	parts := make([]string, 0, len(values))
+	for _, line := range values {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			parts = append(parts, rd.value(line))
+			continue
+		}
+		parts = append(parts, c.Name+"="+rd.value(c.Value))
+	}
+	return strings.Join(parts, ",")
+}
+
+// redacts reports whether the value of name is redacted. When an allowlist
+// is set, every name which is not on it is redacted.
+func (rd redactor) redacts(name string, deny, allow map[string]struct{}) bool {
+	if allow != nil {
+		_, ok := allow[name]
+		return !ok
+	}
+	_, ok := deny[name]
+	return ok
+}
+
+// value returns the replacement of a redacted value.
+func (rd redactor) value(v string) string {
+	if rd.key == nil {
+		return Redacted
+	}
+	mac := hmac.New(sha256.New, rd.key)
+	mac.Write([]byte(v))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// lowerSet returns the lower case names as a set, or nil if names is nil.
+func lowerSet(names []string) map[string]struct{} {
+	if names == nil {
+		return nil
+	}
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,122 @@
+package httplogger
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string][]string
+		cfg     Config
+		want    map[string]string
+	}{
+		{
+			name:   "Default Denylist",
+			target: "/?token=abc&page=2",
+			headers: map[string][]string{
+				"Authorization": {"Bearer secret"},
+				"X-Api-Key":     {"12345"},
+				"Accept":        {"text/html"},
+			},
+			want: map[string]string{
+				"http.request.header.authorization": Redacted,
+				"http.request.header.x-api-key":     Redacted,
+				"http.request.header.accept":        "text/html",
+				"url.query":                         "?page=2&token=[REDACTED]",
+			},
+		},
+		{
+			name:   "Cookie Names Are Kept",
+			target: "/",
+			headers: map[string][]string{
+				"Cookie": {"session=s3cr3t; theme=dark"},
+			},
+			want: map[string]string{
+				"http.request.header.cookie": "session=[REDACTED]; theme=[REDACTED]",
+			},
+		},
+		{
+			name:   "Empty Denylist",
+			target: "/?token=abc",
+			headers: map[string][]string{
+				"Authorization": {"Bearer secret"},
+			},
+			cfg: Config{RedactHeaders: []string{}, RedactQueryParams: []string{}},
+			want: map[string]string{
+				"http.request.header.authorization": "Bearer secret",
+				"url.query":                         "?token=abc",
+			},
+		},
+		{
+			name:   "Allowlist",
+			target: "/?page=2&q=hello+world",
+			headers: map[string][]string{
+				"Accept":     {"text/html"},
+				"X-Internal": {"value"},
+			},
+			cfg: Config{
+				AllowHeaders:     []string{"accept"},
+				AllowQueryParams: []string{"page"},
+			},
+			want: map[string]string{
+				"http.request.header.accept":     "text/html",
+				"http.request.header.x-internal": Redacted,
+				"url.query":                      "?page=2&q=[REDACTED]",
+			},
+		},
+		{
+			name:   "Hashed",
+			target: "/?token=abc",
+			headers: map[string][]string{
+				"Authorization": {"Bearer secret"},
+			},
+			cfg: Config{HashRedacted: true, RedactionKey: []byte("test-key")},
+			want: map[string]string{
+				"http.request.header.authorization": "hmac:3032949bfc0ee5f0",
+				"url.query":                         "?token=hmac:5d0ea494ece26078",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			for k, v := range tt.headers {
+				req.Header[k] = v
+			}
+
+			got := make(map[string]string)
+			for _, a := range requestAttributes(req, tt.cfg, newRedactor(tt.cfg), resolveClient(req, tt.cfg)) {
+				got[a.Key] = a.Value.String()
+			}
+
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+			for k, v := range got {
+				if strings.Contains(v, "secret") && tt.cfg.RedactHeaders == nil {
+					t.Errorf("%s = %q leaks a secret", k, v)
+				}
+			}
+		})
+	}
+}
+
+func TestHashedRedactionKey(t *testing.T) {
+	first := newRedactor(Config{HashRedacted: true}).value("1234")
+	second := newRedactor(Config{HashRedacted: true}).value("1234")
+	keyed := newRedactor(Config{HashRedacted: true, RedactionKey: []byte("test-key")}).value("1234")
+
+	if !strings.HasPrefix(first, "hmac:") || first != second {
+		t.Errorf("expected equal hashes with the process key, got %q and %q", first, second)
+	}
+	if first == keyed {
+		t.Errorf("expected the process key to differ from the configured key, got %q", first)
+	}
+}
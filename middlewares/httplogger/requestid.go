@@ -0,0 +1,138 @@
+package httplogger
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxRequestIDLength is the maximum length of an incoming request ID
+// accepted by ValidRequestID.
+const MaxRequestIDLength = 128
+
+// requestIDHeader returns the header which carries the request ID.
+func requestIDHeader(cfg Config) string {
+	if cfg.RequestIDHeader != "" {
+		return cfg.RequestIDHeader
+	}
+	return RequestIDHeader
+}
+
+// requestIDOf returns the request ID from the request ID header of r, or a
+// generated one if the header is missing or invalid. An invalid ID is
+// returned as rejected, shortened to MaxRequestIDLength bytes.
+func requestIDOf(r *http.Request, cfg Config) (requestID, rejected string) {
+	validate := cfg.ValidateRequestID
+	if validate == nil {
+		validate = ValidRequestID
+	}
+	generate := cfg.GenerateRequestID
+	if generate == nil {
+		generate = UUIDv4
+	}
+
+	requestID = r.Header.Get(requestIDHeader(cfg))
+	if requestID == "" {
+		return generate(), ""
+	}
+	if validate(requestID) {
+		return requestID, ""
+	}
+	rejected = requestID
+	if len(rejected) > MaxRequestIDLength {
+		rejected = rejected[:MaxRequestIDLength] + "..."
+	}
+	return generate(), rejected
+}
+
+// ValidRequestID reports whether id is an acceptable incoming request ID:
+// at most MaxRequestIDLength bytes of printable ASCII without spaces.
+// It is the default of Config.ValidateRequestID.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > MaxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// UUIDv4 generates a random UUID. It is the default of
+// Config.GenerateRequestID.
+func UUIDv4() string {
+	return uuid.NewString()
+}
+
+// UUIDv7 generates a time-ordered UUID.
+func UUIDv7() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID generates a Universally Unique Lexicographically Sortable Identifier:
+// a 48 bit millisecond timestamp followed by 80 random bits, encoded as 26
+// characters of Crockford base32.
+func ULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	_, _ = rand.Read(b[6:])
+
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// ksuidEpoch is the KSUID epoch, 2014-05-13T16:53:20Z.
+const ksuidEpoch = 1400000000
+
+// base62 is the alphabet used by KSUIDs.
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// KSUID generates a K-Sortable Unique Identifier: a 32 bit timestamp in
+// seconds since the KSUID epoch followed by 128 random bits, encoded as 27
+// characters of base62.
+func KSUID() string {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch)) // nolint: gosec // Fits until 2150.
+	_, _ = rand.Read(b[4:])
+
+	// Divide the 160 bit number, as five big-endian 32 bit words, by 62
+	// repeatedly to encode it from the least significant digit.
+	var words [5]uint32
+	for i := range words {
+		words[i] = binary.BigEndian.Uint32(b[i*4:])
+	}
+	var out [27]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		var rem uint64
+		for j := range words {
+			v := rem<<32 | uint64(words[j])
+			words[j] = uint32(v / 62)
+			rem = v % 62
+		}
+		out[i] = base62[rem]
+	}
+	return string(out[:])
+}
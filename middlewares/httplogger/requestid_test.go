@@ -0,0 +1,126 @@
+package httplogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"abc-123", true},
+		{"Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1", true},
+		{"", false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"ünïcode", false},
+		{strings.Repeat("a", MaxRequestIDLength), true},
+		{strings.Repeat("a", MaxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.id); got != tt.want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		name     string
+		generate func() string
+		length   int
+		alphabet string
+		// resolution is the time after which IDs sort, or zero to skip the
+		// check.
+		resolution time.Duration
+	}{
+		{"ULID", ULID, 26, crockford, 2 * time.Millisecond},
+		{"KSUID", KSUID, 27, base62, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := tt.generate()
+			if len(first) != tt.length {
+				t.Errorf("length = %d, want %d", len(first), tt.length)
+			}
+			if i := strings.IndexFunc(first, func(r rune) bool { return !strings.ContainsRune(tt.alphabet, r) }); i >= 0 {
+				t.Errorf("%q contains invalid character at %d", first, i)
+			}
+			if tt.resolution == 0 {
+				return
+			}
+			time.Sleep(tt.resolution)
+			if second := tt.generate(); second <= first {
+				t.Errorf("%q is not sorted after %q", second, first)
+			}
+		})
+	}
+
+	id, err := uuid.Parse(UUIDv7())
+	if err != nil || id.Version() != 7 {
+		t.Errorf("UUIDv7() = %v, %v, want a version 7 UUID", id, err)
+	}
+}
+
+func TestRequestIDConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		incoming     string
+		wantID       string
+		wantRejected string
+	}{
+		{"Valid", "abc-123", "abc-123", ""},
+		{"Missing", "", "generated", ""},
+		{"Invalid", "bad id", "generated", "bad id"},
+		{"Oversized", strings.Repeat("a", 200), "generated", strings.Repeat("a", MaxRequestIDLength) + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			mw := RequestScoped(Config{
+				BaseHandler:       slog.NewJSONHandler(buf, nil),
+				RequestIDHeader:   "X-Correlation-ID",
+				GenerateRequestID: func() string { return "generated" },
+				EchoRequestID:     true,
+			})
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Correlation-ID", tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("X-Correlation-ID"); got != tt.wantID {
+				t.Errorf("echoed request ID = %q, want %q", got, tt.wantID)
+			}
+			if tt.wantRejected == "" {
+				if buf.Len() > 0 {
+					t.Errorf("unexpected log output: %s", buf)
+				}
+				return
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid JSON entry: %v", err)
+			}
+			if entry["request.id.rejected"] != tt.wantRejected || entry["request.id"] != tt.wantID {
+				t.Errorf("unexpected entry: %v", entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package httplogger
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseWriter wraps an http.ResponseWriter to record the status code and
+// the number of bytes written. It implements io.ReaderFrom and unwraps to the
+// original writer for http.ResponseController. Handlers get it through wrap,
+// which adds http.Flusher and http.Hijacker when the original writer has them.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+	hijacked    bool
+	// capture optionally records the start of the response body.
+	capture *bodyCapture
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w}
+}
+
+// Status returns the status code sent to the client, 200 if the handler
+// wrote nothing, or 0 if the connection was hijacked.
+func (w *responseWriter) Status() int {
+	if w.hijacked && !w.wroteHeader {
+		return 0
+	}
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		// Informational responses, except 101, are followed by a final one.
+		if code >= 200 || code == http.StatusSwitchingProtocols {
+			w.wroteHeader = true
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	if w.capture != nil {
+		_, _ = w.capture.Write(b[:n])
+	}
+	return n, err
+}
+
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.capture != nil {
+		src = io.TeeReader(src, w.capture)
+	}
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(writerOnly{w.ResponseWriter}, src)
+	}
+	w.bytes += n
+	return n, err
+}
+
+func (w *responseWriter) flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *responseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap is used by http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// wrap returns w with the optional http.Flusher and http.Hijacker interfaces
+// of the original writer, so that handlers can detect them with a type
+// assertion.
+func (w *responseWriter) wrap() http.ResponseWriter {
+	_, isFlusher := w.ResponseWriter.(http.Flusher)
+	_, isHijacker := w.ResponseWriter.(http.Hijacker)
+	switch {
+	case isFlusher && isHijacker:
+		return flushHijacker{w}
+	case isFlusher:
+		return flusher{w}
+	case isHijacker:
+		return hijacker{w}
+	default:
+		return w
+	}
+}
+
+type flusher struct{ *responseWriter }
+
+func (w flusher) Flush() {
+	w.flush()
+}
+
+type hijacker struct{ *responseWriter }
+
+func (w hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+type flushHijacker struct{ *responseWriter }
+
+func (w flushHijacker) Flush() {
+	w.flush()
+}
+
+func (w flushHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// writerOnly hides the io.ReaderFrom implementation of a writer so that
+// io.Copy does not call back into it.
+type writerOnly struct {
+	io.Writer
+}
+
+// bodyCounter wraps a request body to count the bytes read by the handler
+// and optionally record the start of the body.
+type bodyCounter struct {
+	io.ReadCloser
+	bytes   int64
+	capture *bodyCapture
+}
+
+func (b *bodyCounter) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	if b.capture != nil {
+		_, _ = b.capture.Write(p[:n])
+	}
+	return n, err
+}
@@ -0,0 +1,92 @@
+package httplogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseWriterKeepsInterfaces(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newResponseWriter(rec).wrap()
+
+	if _, ok := w.(http.Flusher); !ok {
+		t.Errorf("expected http.Flusher")
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		t.Errorf("expected no http.Hijacker, as the recorder has none")
+	}
+	if _, ok := newResponseWriter(struct{ http.ResponseWriter }{rec}).wrap().(http.Flusher); ok {
+		t.Errorf("expected no http.Flusher for a writer without one")
+	}
+	if _, err := w.(io.ReaderFrom).ReadFrom(strings.NewReader("hello")); err != nil {
+		t.Errorf("unexpected ReadFrom error: %v", err)
+	}
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now()); err == nil {
+		t.Errorf("expected the recorder's unsupported deadline error to be reached through Unwrap")
+	}
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		t.Errorf("unexpected Flush error: %v", err)
+	}
+	if !rec.Flushed || rec.Body.String() != "hello" {
+		t.Errorf("expected flushed body `hello`, got flushed=%v body=%q", rec.Flushed, rec.Body.String())
+	}
+}
+
+func TestCompletionRecord(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantLevel string
+	}{
+		{"ok", http.StatusOK, "INFO"},
+		{"not found", http.StatusNotFound, "WARN"},
+		{"unavailable", http.StatusServiceUnavailable, "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			mw := RequestScoped(Config{
+				BaseHandler: slog.NewJSONHandler(buf, nil),
+				LogResponse: true,
+			})
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("response"))
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("request body"))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid JSON entry: %v", err)
+			}
+			want := map[string]any{
+				"level":                     tt.wantLevel,
+				"msg":                       "Completed HTTP request",
+				"http.response.status_code": float64(tt.status),
+				"http.response.body.size":   float64(len("response")),
+				"http.request.body.size":    float64(len("request body")),
+				"http.request.method":       http.MethodPost,
+				"url.path":                  "/orders",
+			}
+			for k, v := range want {
+				if entry[k] != v {
+					t.Errorf("attribute %s = %v, want %v", k, entry[k], v)
+				}
+			}
+			if _, ok := entry["http.server.request.duration"].(float64); !ok {
+				t.Errorf("expected duration attribute, got %v", entry["http.server.request.duration"])
+			}
+		})
+	}
+}
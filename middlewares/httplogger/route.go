@@ -0,0 +1,37 @@
+package httplogger
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// ServeMuxRoute returns the path of the pattern which http.ServeMux matched
+// for r, such as "/users/{id}" for the pattern "GET example.com/users/{id}".
+// It returns an empty string before routing.
+func ServeMuxRoute(r *http.Request) string {
+	pattern := r.Pattern
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		pattern = strings.TrimLeft(pattern[i:], " \t")
+	}
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
+
+// routeOf returns the route of r using Config.RouteExtractor or ServeMuxRoute.
+func routeOf(r *http.Request, cfg Config) string {
+	if cfg.RouteExtractor != nil {
+		return cfg.RouteExtractor(r)
+	}
+	return ServeMuxRoute(r)
+}
+
+// routeAttrs returns the http.route attribute of r, if the route is known.
+func routeAttrs(r *http.Request, cfg Config) []slog.Attr {
+	if route := routeOf(r, cfg); route != "" {
+		return []slog.Attr{slog.String("http.route", route)}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package httplogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dusted-go/logging/v2/slogctx"
+)
+
+func TestServeMuxRoute(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"", ""},
+		{"/users/{id}", "/users/{id}"},
+		{"GET /users/{id}", "/users/{id}"},
+		{"GET example.com/users/{id}", "/users/{id}"},
+		{"example.com/", "/"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Pattern = tt.pattern
+		if got := ServeMuxRoute(r); got != tt.want {
+			t.Errorf("ServeMuxRoute(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestRouteAttribute(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			name: "ServeMux",
+			want: "/users/{id}",
+		},
+		{
+			name: "Extractor",
+			cfg: Config{RouteExtractor: func(r *http.Request) string {
+				return r.Pattern
+			}},
+			want: "GET /users/{id}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			cfg := tt.cfg
+			cfg.BaseHandler = slog.NewJSONHandler(buf, nil)
+			cfg.LogRequest = true
+			cfg.LogResponse = true
+			cfg.AddRouteToLogger = true
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+				slogctx.GetLogger(r.Context()).WithGroup("app").Info("Loading user")
+			})
+			RequestScoped(cfg)(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 3 {
+				t.Fatalf("expected 3 entries, got %d: %s", len(lines), buf)
+			}
+			for i, want := range []string{"", tt.want, tt.want} {
+				if got := strings.Count(lines[i], `"http.route"`); got != min(len(want), 1) {
+					t.Errorf("entry %d has %d http.route attributes: %s", i, got, lines[i])
+				}
+				var entry map[string]any
+				if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+					t.Fatalf("invalid JSON entry: %v", err)
+				}
+				if want != "" && entry["http.route"] != want {
+					t.Errorf("entry %d http.route = %v, want %q", i, entry["http.route"], want)
+				}
+			}
+		})
+	}
+}
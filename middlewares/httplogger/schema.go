@@ -0,0 +1,206 @@
+package httplogger
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Schema selects the attribute keys of the records written by RequestScoped.
+type Schema int
+
+const (
+	// SchemaOTel follows the stable OpenTelemetry HTTP Server Semantic
+	// Conventions. It is the default.
+	SchemaOTel Schema = iota
+	// SchemaGCP groups the request attributes as the httpRequest special
+	// field of Cloud Logging and writes the trace as Cloud Logging fields.
+	// More info: https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest
+	SchemaGCP
+	// SchemaECS follows the Elastic Common Schema.
+	// More info: https://www.elastic.co/guide/en/ecs/current/ecs-http.html
+	SchemaECS
+)
+
+func (s Schema) String() string {
+	switch s {
+	case SchemaOTel:
+		return "otel"
+	case SchemaGCP:
+		return "gcp"
+	case SchemaECS:
+		return "ecs"
+	default:
+		return "Schema(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// requestIDAttr returns the attribute which carries the request ID.
+func (s Schema) requestIDAttr(requestID string) slog.Attr {
+	switch s {
+	case SchemaGCP:
+		return slog.String("requestId", requestID)
+	case SchemaECS:
+		return slog.String("http.request.id", requestID)
+	default:
+		return slog.String("request.id", requestID)
+	}
+}
+
+// traceAttrs returns the attributes which carry the trace and span IDs.
+func (s Schema) traceAttrs(span trace.SpanContext, gcpProjectID string) []slog.Attr {
+	switch s {
+	case SchemaGCP:
+		return []slog.Attr{
+			slog.String("logging.googleapis.com/trace",
+				fmt.Sprintf("projects/%s/traces/%s", gcpProjectID, span.TraceID())),
+			slog.String("logging.googleapis.com/spanId", span.SpanID().String()),
+			slog.Bool("logging.googleapis.com/trace_sampled", span.IsSampled()),
+		}
+	case SchemaECS:
+		return []slog.Attr{
+			slog.String("trace.id", span.TraceID().String()),
+			slog.String("span.id", span.SpanID().String()),
+		}
+	default:
+		return []slog.Attr{
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		}
+	}
+}
+
+// convert maps attributes with OpenTelemetry keys to the schema.
+func (s Schema) convert(attrs []slog.Attr) []slog.Attr {
+	switch s {
+	case SchemaGCP:
+		return gcpAttrs(attrs)
+	case SchemaECS:
+		return ecsAttrs(attrs)
+	default:
+		return attrs
+	}
+}
+
+// ecsAttrs renames the attributes which differ in the Elastic Common Schema.
+func ecsAttrs(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		switch a.Key {
+		case "network.protocol.name":
+			a.Key = "network.protocol"
+		case "network.protocol.version":
+			a.Key = "http.version"
+		case "network.peer.address":
+			a.Key = "source.address"
+		case "network.peer.port":
+			a.Key = "source.port"
+		case "http.request.size", "http.request.body.size":
+			// The size of a request without Content-Length is unknown.
+			if a.Value.Int64() < 0 {
+				continue
+			}
+			a.Key = "http.request.body.bytes"
+		case "http.response.body.size":
+			a.Key = "http.response.body.bytes"
+		case "http.request.body":
+			a.Key = "http.request.body.content"
+		case "http.response.body":
+			a.Key = "http.response.body.content"
+		case "url.query":
+			a = slog.String("url.query", strings.TrimPrefix(a.Value.String(), "?"))
+		case "http.server.request.duration":
+			a = slog.Int64("event.duration", secondsToDuration(a.Value).Nanoseconds())
+		case "tls.protocol.name":
+			a.Key = "tls.version_protocol"
+		case "tls.protocol.version":
+			a.Key = "tls.version"
+		case "tls.server_name":
+			a.Key = "tls.client.server_name"
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// gcpAttrs moves the attributes which are part of the Cloud Logging
+// httpRequest field into a group. The remaining attributes are kept.
+func gcpAttrs(attrs []slog.Attr) []slog.Attr {
+	var (
+		scheme, host, path, query string
+		port                      int64 = -1
+		protoName, protoVersion   string
+		httpRequest               []slog.Attr
+	)
+	rest := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		switch a.Key {
+		case "url.scheme":
+			scheme = a.Value.String()
+		case "server.address":
+			host = a.Value.String()
+		case "server.port":
+			port = a.Value.Int64()
+		case "url.path":
+			path = a.Value.String()
+			rest = append(rest, a)
+		case "url.query":
+			query = a.Value.String()
+			rest = append(rest, a)
+		case "network.protocol.name":
+			protoName = a.Value.String()
+		case "network.protocol.version":
+			protoVersion = a.Value.String()
+		case "http.request.method":
+			httpRequest = append(httpRequest, slog.String("requestMethod", a.Value.String()))
+		case "http.request.size", "http.request.body.size":
+			// The size of a request without Content-Length is unknown.
+			if a.Value.Int64() >= 0 {
+				httpRequest = append(httpRequest, slog.String("requestSize", a.Value.String()))
+			}
+		case "http.response.status_code":
+			httpRequest = append(httpRequest, slog.Int64("status", a.Value.Int64()))
+		case "http.response.body.size":
+			httpRequest = append(httpRequest, slog.String("responseSize", a.Value.String()))
+		case "user_agent.original":
+			httpRequest = append(httpRequest, slog.String("userAgent", a.Value.String()))
+		case "http.request.header.referer":
+			httpRequest = append(httpRequest, slog.String("referer", a.Value.String()))
+		case "client.address":
+			httpRequest = append(httpRequest, slog.String("remoteIp", a.Value.String()))
+		case "http.server.request.duration":
+			latency := strconv.FormatFloat(secondsToDuration(a.Value).Seconds(), 'f', -1, 64) + "s"
+			httpRequest = append(httpRequest, slog.String("latency", latency))
+		default:
+			rest = append(rest, a)
+		}
+	}
+
+	// The URL is only complete in request records. Otherwise the path and
+	// query are kept as they are.
+	if path != "" && scheme != "" && host != "" {
+		if port != -1 && !(scheme == "http" && port == 80) && !(scheme == "https" && port == 443) {
+			host += ":" + strconv.FormatInt(port, 10)
+		}
+		httpRequest = append(httpRequest, slog.String("requestUrl", scheme+"://"+host+path+query))
+		rest = slices.DeleteFunc(rest, func(a slog.Attr) bool {
+			return a.Key == "url.path" || a.Key == "url.query"
+		})
+	}
+	if protoName != "" && protoVersion != "" {
+		httpRequest = append(httpRequest, slog.String("protocol", strings.ToUpper(protoName)+"/"+protoVersion))
+	}
+	if len(httpRequest) == 0 {
+		return rest
+	}
+	return append([]slog.Attr{slog.Attr{Key: "httpRequest", Value: slog.GroupValue(httpRequest...)}}, rest...)
+}
+
+func secondsToDuration(v slog.Value) time.Duration {
+	return time.Duration(v.Float64() * float64(time.Second))
+}
@@ -0,0 +1,152 @@
+package httplogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSchemas(t *testing.T) {
+	tests := []struct {
+		schema  Schema
+		want    map[string]any
+		notWant []string
+	}{
+		{
+			schema: SchemaOTel,
+			want: map[string]any{
+				"request.id":                "abc-123",
+				"trace_id":                  "4bf92f3577b34da6a3ce929d0e0e4736",
+				"span_id":                   "00f067aa0ba902b7",
+				"http.request.method":       "POST",
+				"url.path":                  "/orders",
+				"http.response.status_code": float64(201),
+			},
+		},
+		{
+			schema: SchemaGCP,
+			want: map[string]any{
+				"requestId":                            "abc-123",
+				"logging.googleapis.com/trace":         "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+				"logging.googleapis.com/spanId":        "00f067aa0ba902b7",
+				"logging.googleapis.com/trace_sampled": true,
+			},
+			notWant: []string{"http.request.method", "client.address"},
+		},
+		{
+			schema: SchemaECS,
+			want: map[string]any{
+				"http.request.id":           "abc-123",
+				"trace.id":                  "4bf92f3577b34da6a3ce929d0e0e4736",
+				"span.id":                   "00f067aa0ba902b7",
+				"http.request.method":       "POST",
+				"url.query":                 "page=2",
+				"http.version":              "1.1",
+				"http.request.body.bytes":   float64(2),
+				"http.response.status_code": float64(201),
+			},
+			notWant: []string{"network.protocol.version", "http.server.request.duration"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema.String(), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			mw := RequestScoped(Config{
+				BaseHandler:        slog.NewJSONHandler(buf, nil),
+				Schema:             tt.schema,
+				GCPProjectID:       "my-project",
+				AddTrace:           true,
+				AddRequestToLogger: true,
+				LogResponse:        true,
+			})
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			span := trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+				SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+				TraceFlags: trace.FlagsSampled,
+			})
+			ctx := trace.ContextWithSpanContext(context.Background(), span)
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/orders?page=2", strings.NewReader("{}"))
+			req.Header.Set(RequestIDHeader, "abc-123")
+			req.Header.Set("Referer", "https://example.com/")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if n := strings.Count(buf.String(), "\n"); n != 1 {
+				t.Fatalf("expected 1 entry, got %d: %s", n, buf)
+			}
+			for _, key := range []string{"httpRequest", "url.path", "request.id"} {
+				if n := strings.Count(buf.String(), `"`+key+`":`); n > 1 {
+					t.Errorf("%s appears %d times: %s", key, n, buf)
+				}
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid JSON entry: %v", err)
+			}
+			for k, v := range tt.want {
+				if !reflect.DeepEqual(entry[k], v) {
+					t.Errorf("%s = %v, want %v", k, entry[k], v)
+				}
+			}
+			for _, k := range tt.notWant {
+				if _, ok := entry[k]; ok {
+					t.Errorf("unexpected attribute %s", k)
+				}
+			}
+		})
+	}
+}
+
+func TestGCPHTTPRequest(t *testing.T) {
+	attrs := gcpAttrs([]slog.Attr{
+		slog.String("server.address", "example.com"),
+		slog.Int("server.port", 8443),
+		slog.String("network.protocol.name", "http"),
+		slog.String("network.protocol.version", "2"),
+		slog.String("http.request.method", "GET"),
+		slog.Int64("http.request.size", -1),
+		slog.String("url.path", "/orders"),
+		slog.String("url.scheme", "https"),
+		slog.String("url.query", "?page=2"),
+		slog.String("client.address", "203.0.113.7"),
+		slog.String("http.request.header.accept", "*/*"),
+		slog.Int("http.response.status_code", 200),
+		slog.Int64("http.response.body.size", 512),
+		slog.Float64("http.server.request.duration", 1.5),
+	})
+
+	want := []slog.Attr{
+		slog.Group("httpRequest",
+			slog.String("requestMethod", "GET"),
+			slog.String("remoteIp", "203.0.113.7"),
+			slog.Int64("status", 200),
+			slog.String("responseSize", "512"),
+			slog.String("latency", "1.5s"),
+			slog.String("requestUrl", "https://example.com:8443/orders?page=2"),
+			slog.String("protocol", "HTTP/2"),
+		),
+		slog.String("http.request.header.accept", "*/*"),
+	}
+	if len(attrs) != len(want) {
+		t.Fatalf("got %v, want %v", attrs, want)
+	}
+	for i := range want {
+		if !attrs[i].Equal(want[i]) {
+			t.Errorf("attribute %d = %v, want %v", i, attrs[i], want[i])
+		}
+	}
+}
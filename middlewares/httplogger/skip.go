@@ -0,0 +1,75 @@
+package httplogger
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Rule matches requests whose logs are skipped or sampled, such as health
+// checks and metrics scrapes. A request matches when it matches every
+// criterion which is set, and any of the values of a criterion. A Rule
+// without criteria matches every request.
+type Rule struct {
+	// PathPrefixes match the URL path by prefix, such as "/debug/".
+	PathPrefixes []string
+	// Paths match the URL path with path.Match patterns, such as
+	// "/healthz" or "/api/*/status".
+	Paths []string
+	// Methods match the request method, such as "OPTIONS".
+	Methods []string
+	// UserAgents match substrings of the User-Agent header, such as
+	// "kube-probe".
+	UserAgents []string
+	// Match optionally matches requests with a custom predicate.
+	Match func(r *http.Request) bool
+	// SampleRate is the fraction of matching requests, between 0 and 1,
+	// which are still logged. Zero skips every matching request.
+	SampleRate float64
+}
+
+// Matches reports whether r matches the rule.
+func (rule Rule) Matches(r *http.Request) bool {
+	if len(rule.PathPrefixes) > 0 && !matchAny(rule.PathPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	}) {
+		return false
+	}
+	if len(rule.Paths) > 0 && !matchAny(rule.Paths, func(pattern string) bool {
+		ok, err := path.Match(pattern, r.URL.Path)
+		return err == nil && ok
+	}) {
+		return false
+	}
+	if len(rule.Methods) > 0 && !matchAny(rule.Methods, func(method string) bool {
+		return strings.EqualFold(method, r.Method)
+	}) {
+		return false
+	}
+	if len(rule.UserAgents) > 0 && !matchAny(rule.UserAgents, func(agent string) bool {
+		return strings.Contains(r.UserAgent(), agent)
+	}) {
+		return false
+	}
+	return rule.Match == nil || rule.Match(r)
+}
+
+// shouldLog applies the first of the skip rules which matches r, if any.
+func shouldLog(r *http.Request, rules []Rule) bool {
+	for _, rule := range rules {
+		if rule.Matches(r) {
+			return rule.SampleRate > 0 && rand.Float64() < rule.SampleRate
+		}
+	}
+	return true
+}
+
+func matchAny(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
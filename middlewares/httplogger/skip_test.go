@@ -0,0 +1,93 @@
+package httplogger
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dusted-go/logging/v2/slogctx"
+)
+
+func TestRuleMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      Rule
+		method    string
+		target    string
+		userAgent string
+		want      bool
+	}{
+		{"Empty Rule", Rule{}, "GET", "/orders", "", true},
+		{"Path Prefix", Rule{PathPrefixes: []string{"/debug/"}}, "GET", "/debug/pprof", "", true},
+		{"Path Prefix Mismatch", Rule{PathPrefixes: []string{"/debug/"}}, "GET", "/orders", "", false},
+		{"Path Glob", Rule{Paths: []string{"/api/*/status"}}, "GET", "/api/v1/status", "", true},
+		{"Path Glob Mismatch", Rule{Paths: []string{"/api/*/status"}}, "GET", "/api/v1/x/status", "", false},
+		{"Method", Rule{Methods: []string{"options"}}, "OPTIONS", "/", "", true},
+		{"User Agent", Rule{UserAgents: []string{"kube-probe"}}, "GET", "/", "kube-probe/1.29", true},
+		{"All Criteria Must Match", Rule{Paths: []string{"/healthz"}, Methods: []string{"GET"}}, "POST", "/healthz", "", false},
+		{"Predicate", Rule{Match: func(r *http.Request) bool { return r.URL.Query().Has("probe") }}, "GET", "/?probe", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			r.Header.Set("User-Agent", tt.userAgent)
+			if got := tt.rule.Matches(r); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		status    int
+		delay     time.Duration
+		wantLines []string
+	}{
+		{"Not Skipped", "/orders", http.StatusOK, 0, []string{"Processing HTTP request", "Completed HTTP request"}},
+		{"Skipped", "/healthz", http.StatusOK, 0, nil},
+		{"Skipped Error", "/healthz", http.StatusServiceUnavailable, 0, []string{"Completed HTTP request"}},
+		{"Skipped Slow", "/healthz", http.StatusOK, 20 * time.Millisecond, []string{"Completed HTTP request"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			mw := RequestScoped(Config{
+				BaseHandler:          slog.NewTextHandler(buf, nil),
+				LogRequest:           true,
+				LogResponse:          true,
+				SkipRules:            []Rule{{Paths: []string{"/healthz"}}},
+				SlowRequestThreshold: 10 * time.Millisecond,
+			})
+			var hasLogger bool
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hasLogger = slogctx.RequestID(r.Context()) != "" && slogctx.GetLogger(r.Context()) != slog.Default()
+				time.Sleep(tt.delay)
+				w.WriteHeader(tt.status)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if !hasLogger {
+				t.Errorf("expected request-scoped logger in context")
+			}
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				if _, msg, ok := strings.Cut(line, "msg=\""); ok {
+					msg, _, _ = strings.Cut(msg, "\"")
+					got = append(got, msg)
+				}
+			}
+			if strings.Join(got, "|") != strings.Join(tt.wantLines, "|") {
+				t.Errorf("logged %q, want %q", got, tt.wantLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package httplogger
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// tlsAttributes extracts attributes from the TLS connection of a request.
+// It follows the OpenTelemetry TLS attributes, and the Elastic Common Schema
+// for the serial number and alternative names of the client certificate.
+func tlsAttributes(state *tls.ConnectionState) []slog.Attr {
+	// https://opentelemetry.io/docs/specs/semconv/attributes-registry/tls/
+	name, version, ok := strings.Cut(tls.VersionName(state.Version), " ")
+	switch {
+	case state.Version == tls.VersionSSL30: // nolint: staticcheck // Deprecated, but still negotiated by old clients.
+		name, version = "ssl", "3"
+	case !ok:
+		name, version = "tls", tls.VersionName(state.Version)
+	}
+	attrs := []slog.Attr{
+		slog.String("tls.protocol.name", strings.ToLower(name)),
+		slog.String("tls.protocol.version", version),
+		slog.String("tls.cipher", tls.CipherSuiteName(state.CipherSuite)),
+		slog.Bool("tls.established", state.HandshakeComplete),
+		slog.Bool("tls.resumed", state.DidResume),
+	}
+	if state.ServerName != "" {
+		attrs = append(attrs, slog.String("tls.server_name", state.ServerName))
+	}
+
+	if len(state.PeerCertificates) == 0 {
+		return attrs
+	}
+	cert := state.PeerCertificates[0]
+	var names []string
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	attrs = append(attrs,
+		slog.String("tls.client.subject", cert.Subject.String()),
+		slog.String("tls.client.issuer", cert.Issuer.String()),
+		slog.String("tls.client.not_before", cert.NotBefore.UTC().Format(time.RFC3339)),
+		slog.String("tls.client.not_after", cert.NotAfter.UTC().Format(time.RFC3339)),
+		slog.String("tls.client.x509.serial_number", cert.SerialNumber.Text(16)),
+	)
+	if len(names) > 0 {
+		attrs = append(attrs, slog.Any("tls.client.x509.alternative_names", names))
+	}
+	return attrs
+}
+
+// alpnProtocol returns the network.protocol.name and network.protocol.version
+// of an ALPN protocol ID, such as "http" and "2" for "h2". The version is
+// empty if the ID has none.
+func alpnProtocol(id string) (string, string) {
+	// https://www.iana.org/assignments/tls-extensiontype-values/tls-extensiontype-values.xhtml#alpn-protocol-ids
+	switch id {
+	case "h2", "h2c":
+		return "http", "2"
+	case "h3":
+		return "http", "3"
+	}
+	name, version, _ := strings.Cut(id, "/")
+	return strings.ToLower(name), version
+}
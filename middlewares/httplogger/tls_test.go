@@ -0,0 +1,132 @@
+package httplogger
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTLSAttributes(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0x1f),
+		Subject:      pkix.Name{CommonName: "billing"},
+		Issuer:       pkix.Name{CommonName: "billing"},
+		NotBefore:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		DNSNames:     []string{"billing.internal"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		state   *tls.ConnectionState
+		want    map[string]any
+		notWant []string
+	}{
+		{
+			name: "Disabled",
+			state: &tls.ConnectionState{
+				Version: tls.VersionTLS13,
+			},
+			notWant: []string{"tls.protocol.version"},
+		},
+		{
+			name: "Server Only",
+			cfg:  Config{LogTLS: true},
+			state: &tls.ConnectionState{
+				Version:            tls.VersionTLS13,
+				CipherSuite:        tls.TLS_AES_128_GCM_SHA256,
+				HandshakeComplete:  true,
+				ServerName:         "api.example.com",
+				NegotiatedProtocol: "h2",
+			},
+			want: map[string]any{
+				"tls.protocol.name":        "tls",
+				"tls.protocol.version":     "1.3",
+				"tls.cipher":               "TLS_AES_128_GCM_SHA256",
+				"tls.established":          true,
+				"tls.resumed":              false,
+				"tls.server_name":          "api.example.com",
+				"network.protocol.name":    "http",
+				"network.protocol.version": "2",
+			},
+			notWant: []string{"tls.client.subject"},
+		},
+		{
+			name: "SSLv3",
+			cfg:  Config{LogTLS: true},
+			state: &tls.ConnectionState{
+				Version:            tls.VersionSSL30, // nolint: staticcheck // Testing the deprecated version.
+				NegotiatedProtocol: "http/1.0",
+			},
+			want: map[string]any{
+				"tls.protocol.name":        "ssl",
+				"tls.protocol.version":     "3",
+				"network.protocol.name":    "http",
+				"network.protocol.version": "1.0",
+			},
+		},
+		{
+			name: "Mutual TLS",
+			cfg:  Config{LogTLS: true},
+			state: &tls.ConnectionState{
+				Version:          tls.VersionTLS12,
+				DidResume:        true,
+				PeerCertificates: []*x509.Certificate{cert},
+			},
+			want: map[string]any{
+				"tls.protocol.version":              "1.2",
+				"tls.resumed":                       true,
+				"tls.client.subject":                "CN=billing",
+				"tls.client.issuer":                 "CN=billing",
+				"tls.client.not_before":             "2026-01-01T00:00:00Z",
+				"tls.client.not_after":              "2027-01-01T00:00:00Z",
+				"tls.client.x509.serial_number":     "1f",
+				"tls.client.x509.alternative_names": []string{"billing.internal"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+			req.TLS = tt.state
+
+			got := make(map[string]any)
+			for _, a := range requestAttributes(req, tt.cfg, newRedactor(tt.cfg), resolveClient(req, tt.cfg)) {
+				got[a.Key] = a.Value.Any()
+			}
+			for k, v := range tt.want {
+				if !reflect.DeepEqual(got[k], v) {
+					t.Errorf("%s = %v, want %v", k, got[k], v)
+				}
+			}
+			for _, k := range tt.notWant {
+				if _, ok := got[k]; ok {
+					t.Errorf("unexpected attribute %s", k)
+				}
+			}
+		})
+	}
+}
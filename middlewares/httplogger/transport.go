@@ -0,0 +1,45 @@
+package httplogger
+
+import (
+	"net/http"
+)
+
+// PropagateRequestID returns an http.RoundTripper which sets the X-Request-ID
+// header of outbound requests to the request ID carried by their context.
+// Requests which already have the header are left unchanged.
+// If base is nil, http.DefaultTransport is used.
+func PropagateRequestID(base http.RoundTripper) http.RoundTripper {
+	return PropagateRequestIDHeader(base, RequestIDHeader)
+}
+
+// PropagateRequestIDHeader is like PropagateRequestID but sets the given
+// header, which should match Config.RequestIDHeader.
+func PropagateRequestIDHeader(base http.RoundTripper, header string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, roundTrip: func(r *http.Request) (*http.Response, error) {
+		return base.RoundTrip(propagate(r, header, false))
+	}}
+}
+
+// transport is an http.RoundTripper which wraps base. It forwards
+// CloseIdleConnections to base, so that http.Client.CloseIdleConnections
+// still closes the connections of the wrapped transport.
+type transport struct {
+	base      http.RoundTripper
+	roundTrip func(*http.Request) (*http.Response, error)
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return t.roundTrip(r)
+}
+
+func (t *transport) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+	if c, ok := t.base.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,164 @@
+package slogctx
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dusted-go/logging/v2/internal/groupattrs"
+)
+
+// AddAttrs returns a copy of ctx which carries attrs in addition to the
+// attributes already carried by ctx. An attribute replaces an earlier one
+// with the same key.
+//
+// The attributes are added to every record which is logged with ctx by a
+// logger whose handler is wrapped with NewHandler.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(attrs) == 0 {
+		return ctx
+	}
+	existing := Attrs(ctx)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, a := range existing {
+		if !containsKey(attrs, a.Key) {
+			merged = append(merged, a)
+		}
+	}
+	for i, a := range attrs {
+		if !containsKey(attrs[i+1:], a.Key) {
+			merged = append(merged, a)
+		}
+	}
+	return context.WithValue(ctx, attrsKey, merged)
+}
+
+// Attrs returns the attributes carried by ctx.
+func Attrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey).([]slog.Attr)
+	return attrs
+}
+
+func containsKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler is a slog.Handler which applies the values carried by the context
+// passed to each call:
+//   - Enabled checks the level set by WithLevel before the level of the
+//     wrapped handler.
+//   - Handle adds the attributes set by AddAttrs to each record. They are
+//     always added at the top level, even when the logger has open groups,
+//     and are skipped when the record or the logger already sets the key.
+type Handler struct {
+	h      slog.Handler
+	keys   map[string]struct{} // top-level keys set by WithAttrs and WithGroup
+	nested groupattrs.Nested
+}
+
+// NewHandler returns a Handler which writes to h.
+func NewHandler(h slog.Handler) *Handler {
+	return &Handler{h: h}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if minLevel, ok := Level(ctx); ok {
+		return level >= minLevel
+	}
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if h.nested.Empty() {
+		keys := copyKeys(h.keys)
+		addKeys(keys, attrs)
+		return &Handler{h: h.h.WithAttrs(attrs), keys: keys}
+	}
+	return &Handler{h: h.h, keys: h.keys, nested: h.nested.WithAttrs(attrs)}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	keys := h.keys
+	if h.nested.Empty() {
+		keys = copyKeys(h.keys)
+		keys[name] = struct{}{}
+	}
+	return &Handler{h: h.h, keys: keys, nested: h.nested.WithGroup(name)}
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	ctxAttrs := h.unsetAttrs(r, Attrs(ctx))
+	if h.nested.Empty() {
+		if len(ctxAttrs) > 0 {
+			r = r.Clone()
+			r.AddAttrs(ctxAttrs...)
+		}
+		return h.h.Handle(ctx, r)
+	}
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return h.h.Handle(ctx, h.nested.Record(r, ctxAttrs, attrs))
+}
+
+// unsetAttrs returns the attributes of ctxAttrs whose keys are neither set at
+// the top level of r nor by the logger.
+func (h *Handler) unsetAttrs(r slog.Record, ctxAttrs []slog.Attr) []slog.Attr {
+	if len(ctxAttrs) == 0 {
+		return nil
+	}
+	keys := h.keys
+	if h.nested.Empty() && r.NumAttrs() > 0 {
+		keys = copyKeys(h.keys)
+		r.Attrs(func(a slog.Attr) bool {
+			addKeys(keys, []slog.Attr{a})
+			return true
+		})
+	}
+	if len(keys) == 0 {
+		return ctxAttrs
+	}
+	unset := make([]slog.Attr, 0, len(ctxAttrs))
+	for _, a := range ctxAttrs {
+		if _, ok := keys[a.Key]; !ok {
+			unset = append(unset, a)
+		}
+	}
+	return unset
+}
+
+func copyKeys(keys map[string]struct{}) map[string]struct{} {
+	c := make(map[string]struct{}, len(keys)+1)
+	for k := range keys {
+		c[k] = struct{}{}
+	}
+	return c
+}
+
+// addKeys adds the keys of attrs to keys, including the keys of groups with
+// an empty key, which slog inlines.
+func addKeys(keys map[string]struct{}, attrs []slog.Attr) {
+	for _, a := range attrs {
+		if a.Key == "" && a.Value.Kind() == slog.KindGroup {
+			addKeys(keys, a.Value.Group())
+			continue
+		}
+		keys[a.Key] = struct{}{}
+	}
+}
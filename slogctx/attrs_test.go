@@ -0,0 +1,100 @@
+package slogctx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHandlerAddsContextAttrs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(NewHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+
+	ctx := AddAttrs(context.Background(), slog.String("tenant.id", "acme"), slog.String("job.id", "1"))
+	ctx = AddAttrs(ctx, slog.String("job.id", "2"))
+
+	logger.InfoContext(ctx, "plain", "a", 1)
+	logger.WithGroup("req").With("b", 2).InfoContext(ctx, "grouped", "c", 3)
+
+	want := []map[string]any{
+		{"level": "INFO", "msg": "plain", "a": 1.0, "tenant.id": "acme", "job.id": "2"},
+		{"level": "INFO", "msg": "grouped", "tenant.id": "acme", "job.id": "2",
+			"req": map[string]any{"b": 2.0, "c": 3.0}},
+	}
+	dec := json.NewDecoder(buf)
+	for i := range want {
+		var got map[string]any
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("invalid JSON entry %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("entry %d = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestHandlerSkipsContextAttrsWithSetKeys(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(NewHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+
+	ctx := AddAttrs(context.Background(),
+		slog.String("tenant.id", "ctx"), slog.String("job.id", "ctx"), slog.String("req", "ctx"))
+
+	logger.InfoContext(ctx, "record", "job.id", "record")
+	logger.With("tenant.id", "with").InfoContext(ctx, "with", "job.id", "record")
+	logger.With("tenant.id", "with").WithGroup("req").InfoContext(ctx, "grouped", "job.id", "record")
+
+	want := []string{
+		`{"level":"INFO","msg":"record","job.id":"record","tenant.id":"ctx","req":"ctx"}`,
+		`{"level":"INFO","msg":"with","tenant.id":"with","job.id":"record","req":"ctx"}`,
+		`{"level":"INFO","msg":"grouped","tenant.id":"with","job.id":"ctx","req":{"job.id":"record"}}`,
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d entries, got: %q", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestHandlerUsesContextLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(NewHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	logger.DebugContext(context.Background(), "hidden")
+	logger.DebugContext(WithLevel(context.Background(), slog.LevelDebug), "visible")
+	logger.InfoContext(WithLevel(context.Background(), slog.LevelWarn), "suppressed")
+
+	var entry map[string]any
+	dec := json.NewDecoder(buf)
+	if err := dec.Decode(&entry); err != nil {
+		t.Fatalf("invalid JSON entry: %v", err)
+	}
+	if entry["msg"] != "visible" {
+		t.Errorf("expected only the `visible` entry, got: %v", entry)
+	}
+	if dec.More() {
+		t.Errorf("expected a single entry")
+	}
+}
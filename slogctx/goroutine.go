@@ -0,0 +1,148 @@
+package slogctx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+)
+
+// GoroutineNameKey is the attribute key of the goroutine name added by Go.
+const GoroutineNameKey = "goroutine.name"
+
+// GoOption configures Go and NewGroup.
+type GoOption func(c *goConfig)
+
+type goConfig struct {
+	repanic bool
+}
+
+// WithRepanic makes a recovered panic propagate as a *PanicError after it has
+// been logged.
+func WithRepanic() GoOption {
+	return func(c *goConfig) {
+		c.repanic = true
+	}
+}
+
+// PanicError is the error returned by Group.Wait for a recovered panic, and
+// the value re-panicked with by WithRepanic.
+type PanicError struct {
+	Name  string
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in goroutine %q: %v", e.Name, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Go runs fn in a new goroutine with a context which carries the values of
+// ctx, including its logger, but which is not cancelled when ctx is.
+//
+// The logger adds a "goroutine.name" attribute to every record. A panic in
+// fn is recovered and logged at ERROR with its stack.
+func Go(ctx context.Context, name string, fn func(ctx context.Context), opts ...GoOption) {
+	cfg := newGoConfig(opts)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = withGoroutineName(context.WithoutCancel(ctx), name)
+	go run(ctx, name, cfg, fn)
+}
+
+func run(ctx context.Context, name string, cfg goConfig, fn func(ctx context.Context)) {
+	defer recoverPanic(ctx, name, cfg, nil)
+	fn(ctx)
+}
+
+// Group runs goroutines like Go and waits for them to finish. The context
+// passed to each goroutine is cancelled when the first one returns an error
+// or panics, but not when the context passed to NewGroup is.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	cfg    goConfig
+
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup creates a Group which copies the values of ctx, including its
+// logger, into the context of each goroutine.
+func NewGroup(ctx context.Context, opts ...GoOption) *Group {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	return &Group{ctx: ctx, cancel: cancel, cfg: newGoConfig(opts)}
+}
+
+// Go runs fn in a new goroutine of the group.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	ctx := withGoroutineName(g.ctx, name)
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer recoverPanic(ctx, name, g.cfg, g.fail)
+		if err := fn(ctx); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// Wait waits for all goroutines of the group to finish and returns the first
+// error, or a *PanicError if a goroutine panicked first.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	return g.err
+}
+
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel(err)
+	})
+}
+
+func newGoConfig(opts []GoOption) goConfig {
+	var cfg goConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// withGoroutineName returns a copy of ctx whose logger adds the goroutine name.
+func withGoroutineName(ctx context.Context, name string) context.Context {
+	logger := GetLogger(ctx).With(slog.String(GoroutineNameKey, name))
+	return WithLogger(ctx, logger)
+}
+
+func recoverPanic(ctx context.Context, name string, cfg goConfig, fail func(error)) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	stack := debug.Stack()
+	GetLogger(ctx).LogAttrs(ctx, slog.LevelError, "Recovered from panic in goroutine",
+		slog.Any("panic", v),
+		slog.String("stack", string(stack)),
+	)
+	err := &PanicError{Name: name, Value: v, Stack: stack}
+	if fail != nil {
+		fail(err)
+	}
+	if cfg.repanic {
+		panic(err)
+	}
+}
@@ -0,0 +1,116 @@
+package slogctx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestGroupRecoversPanics(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	ctx, cancel := context.WithCancel(WithLogger(context.Background(), logger))
+	cancel()
+
+	g := NewGroup(ctx)
+	g.Go("worker", func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		panic("boom")
+	})
+	err := g.Wait()
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Name != "worker" || panicErr.Value != "boom" {
+		t.Fatalf("expected PanicError from worker, got: %v", err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON entry: %v", err)
+	}
+	if entry["level"] != "ERROR" || entry[GoroutineNameKey] != "worker" || entry["panic"] != "boom" {
+		t.Errorf("unexpected panic entry: %v", entry)
+	}
+	if stack, _ := entry["stack"].(string); stack == "" {
+		t.Errorf("expected stack in panic entry")
+	}
+}
+
+func TestGoPassesLoggerToUncancelledContext(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	ctx, cancel := context.WithCancel(WithLogger(context.Background(), logger))
+	cancel()
+
+	done := make(chan error)
+	Go(ctx, "worker", func(ctx context.Context) {
+		GetLogger(ctx).InfoContext(ctx, "working")
+		done <- ctx.Err()
+	})
+	if err := <-done; err != nil {
+		t.Errorf("expected a context which is not cancelled, got: %v", err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON entry: %v", err)
+	}
+	if entry["msg"] != "working" || entry[GoroutineNameKey] != "worker" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+}
+
+func TestGoRecoversPanics(t *testing.T) {
+	buf := &bytes.Buffer{}
+	done := make(chan struct{})
+	logger := slog.New(slog.NewJSONHandler(&notifyWriter{Buffer: buf, done: done}, nil))
+	ctx := WithLogger(context.Background(), logger)
+
+	Go(ctx, "worker", func(context.Context) {
+		panic("boom")
+	})
+	<-done
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON entry: %v", err)
+	}
+	if entry["level"] != "ERROR" || entry[GoroutineNameKey] != "worker" || entry["panic"] != "boom" {
+		t.Errorf("unexpected panic entry: %v", entry)
+	}
+}
+
+func TestWithRepanicPanicsWithPanicError(t *testing.T) {
+	ctx := WithLogger(context.Background(), slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	cause := errors.New("boom")
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		run(ctx, "worker", newGoConfig([]GoOption{WithRepanic()}), func(context.Context) {
+			panic(cause)
+		})
+	}()
+
+	panicErr, ok := recovered.(*PanicError)
+	if !ok || panicErr.Name != "worker" || !errors.Is(panicErr, cause) {
+		t.Errorf("expected *PanicError wrapping the panic value, got: %v", recovered)
+	}
+}
+
+// notifyWriter closes done after its first write.
+type notifyWriter struct {
+	*bytes.Buffer
+	done chan struct{}
+}
+
+func (w *notifyWriter) Write(p []byte) (int, error) {
+	defer close(w.done)
+	return w.Buffer.Write(p)
+}
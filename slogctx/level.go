@@ -0,0 +1,25 @@
+package slogctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLevel returns a copy of ctx which overrides the minimum level of
+// loggers whose handler is wrapped with NewHandler. The override applies to
+// records logged with ctx, such as through InfoContext or Info of this package.
+func WithLevel(ctx context.Context, level slog.Level) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, levelKey, level)
+}
+
+// Level returns the level override carried by ctx.
+func Level(ctx context.Context) (slog.Level, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	level, ok := ctx.Value(levelKey).(slog.Level)
+	return level, ok
+}
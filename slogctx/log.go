@@ -0,0 +1,109 @@
+package slogctx
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Debug logs at slog.LevelDebug with the logger from ctx.
+func Debug(ctx context.Context, msg string, args ...any) {
+	log(ctx, slog.LevelDebug, msg, args...)
+}
+
+// Info logs at slog.LevelInfo with the logger from ctx.
+func Info(ctx context.Context, msg string, args ...any) {
+	log(ctx, slog.LevelInfo, msg, args...)
+}
+
+// Warn logs at slog.LevelWarn with the logger from ctx.
+func Warn(ctx context.Context, msg string, args ...any) {
+	log(ctx, slog.LevelWarn, msg, args...)
+}
+
+// Error logs at slog.LevelError with the logger from ctx.
+func Error(ctx context.Context, msg string, args ...any) {
+	log(ctx, slog.LevelError, msg, args...)
+}
+
+// Log logs at the given level with the logger from ctx.
+func Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	log(ctx, level, msg, args...)
+}
+
+// LogAttrs is a more efficient version of Log that accepts only Attrs.
+func LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := GetLogger(ctx)
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), level, msg, CallerPC(0))
+	r.AddAttrs(attrs...)
+	_ = logger.Handler().Handle(ctx, r)
+}
+
+func log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := GetLogger(ctx)
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), level, msg, CallerPC(1))
+	r.Add(args...)
+	_ = logger.Handler().Handle(ctx, r)
+}
+
+var (
+	helpers    sync.Map // function name -> struct{}
+	hasHelpers atomic.Bool
+)
+
+// Helper marks the calling function as a logging helper. Functions marked
+// as helpers are skipped when recording the source of a record, so wrappers
+// around the functions of this package report the location of their caller.
+func Helper() {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // skip [Callers, Helper]
+	f, _ := runtime.CallersFrames(pcs[:]).Next()
+	if _, loaded := helpers.LoadOrStore(f.Function, struct{}{}); !loaded {
+		hasHelpers.Store(true)
+	}
+}
+
+// CallerPC returns the program counter of the caller of the function which
+// calls CallerPC, after skipping skip additional frames and any functions
+// marked by Helper. It is meant to be used as the PC of a slog.Record.
+// It returns 0, so that no source is recorded, when all frames are helpers.
+func CallerPC(skip int) uintptr {
+	// Skip [Callers, CallerPC, the function calling CallerPC].
+	skip += 3
+	if !hasHelpers.Load() {
+		var pcs [1]uintptr
+		runtime.Callers(skip, pcs[:])
+		return pcs[0]
+	}
+
+	// Resolve all frames at once, because a PC of an inlined call can
+	// expand to several frames. Frame.PC is the PC of the call instruction,
+	// while a slog.Record holds the return PC as reported by runtime.Callers.
+	var pcs [32]uintptr
+	n := runtime.Callers(skip, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if _, ok := helpers.Load(f.Function); !ok {
+			return f.PC + 1
+		}
+		if !more {
+			return 0
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package slogctx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"testing"
+)
+
+func logThroughHelper(ctx context.Context, msg string) {
+	Helper()
+	Info(ctx, msg)
+}
+
+func TestLogRecordsCallerSource(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{AddSource: true}))
+	ctx := WithLogger(context.Background(), logger)
+
+	_, _, line, _ := runtime.Caller(0)
+	Info(ctx, "direct")
+	LogAttrs(ctx, slog.LevelWarn, "attrs", slog.Int("a", 1))
+	logThroughHelper(ctx, "helper")
+
+	dec := json.NewDecoder(buf)
+	for i, want := range []int{line + 1, line + 2, line + 3} {
+		var entry struct {
+			Source struct {
+				Line int `json:"line"`
+			} `json:"source"`
+			Msg string `json:"msg"`
+		}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("invalid JSON entry %d: %v", i, err)
+		}
+		if entry.Source.Line != want {
+			t.Errorf("%s: source line = %d, want %d", entry.Msg, entry.Source.Line, want)
+		}
+	}
+}
+
+func callerPCThroughHelpers(depth int) uintptr {
+	Helper()
+	if depth == 0 {
+		return CallerPC(0)
+	}
+	return callerPCThroughHelpers(depth - 1)
+}
+
+func TestCallerPCWithOnlyHelpers(t *testing.T) {
+	if pc := callerPCThroughHelpers(64); pc != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		t.Errorf("CallerPC() = %s, want 0", f.Function)
+	}
+	if pc := callerPCThroughHelpers(0); pc == 0 {
+		t.Errorf("CallerPC() = 0, want the caller")
+	}
+}
@@ -7,7 +7,14 @@ import (
 
 type contextKey int
 
-const loggerKey contextKey = 0
+const (
+	loggerKey contextKey = iota
+	nameKey
+	attrsKey
+	requestIDKey
+	traceIDKey
+	levelKey
+)
 
 // WithLogger adds a *slog.Logger to the current context.
 func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
@@ -25,3 +32,48 @@ func GetLogger(ctx context.Context) *slog.Logger {
 	}
 	return slog.Default()
 }
+
+// NameKey is the attribute key of the logger name added by Named.
+const NameKey = "logger.name"
+
+type namedLogger struct {
+	name   string
+	base   *slog.Logger
+	logger *slog.Logger
+}
+
+// Named returns a copy of ctx with a child logger which adds a "logger.name"
+// attribute to every record. Names are hierarchical: calling Named with
+// "invoices" on a context which was already named "billing" results in
+// "billing.invoices".
+func Named(ctx context.Context, name string) (context.Context, *slog.Logger) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := GetLogger(ctx)
+	base := parent
+	if n, ok := ctx.Value(nameKey).(*namedLogger); ok {
+		if n.name != "" {
+			name = n.name + "." + name
+		}
+		// Derive from the unnamed logger to avoid writing the name twice,
+		// unless the logger in ctx was replaced since it was named.
+		if n.logger == parent {
+			base = n.base
+		}
+	}
+	logger := base.With(slog.String(NameKey, name))
+	ctx = context.WithValue(ctx, nameKey, &namedLogger{name: name, base: base, logger: logger})
+	return WithLogger(ctx, logger), logger
+}
+
+// Name returns the name of the logger in ctx, or an empty string.
+func Name(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if n, ok := ctx.Value(nameKey).(*namedLogger); ok {
+		return n.name
+	}
+	return ""
+}